@@ -72,7 +72,10 @@ func run(ctx context.Context, tests ...*Test) error {
 	doc.Body().AppendChildren()
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
-	glx := gl.NewContext(canvasElem)
+	glx, err := gl.NewContext(canvasElem)
+	if err != nil {
+		return fmt.Errorf("creating gl context: %w", err)
+	}
 	defer glx.Destroy()
 
 	glx.Viewport(0, 0, fbWidth, fbHeight)
@@ -62,25 +62,14 @@ void main(void) {
 	glx.Targets().Texture2D().GenerateMipmap()
 	glx.Targets().Texture2D().Unbind()
 
-	program := glx.CreateProgram()
-	defer program.Destroy()
-	vShader := glx.CreateShader(gl.VertexShader)
-	defer vShader.Destroy()
-	vShader.Source(vSource)
-	fShader := glx.CreateShader(gl.FragmentShader)
-	defer fShader.Destroy()
-	fShader.Source(fSource)
-	vShader.Compile()
-	fShader.Compile()
-	program.Attach(vShader)
-	program.Attach(fShader)
-	program.Link()
-	if !program.LinkSuccess() {
-		glx.Log("vert shader log: %s", vShader.InfoLog())
-		glx.Log("frag shader log: %s", fShader.InfoLog())
-		glx.Log("prog linker log: %s", program.InfoLog())
-		return fmt.Errorf("complex error linking program, see log")
+	program, err := glx.BuildProgram(gl.ProgramConfig{
+		VertexSource:   vSource,
+		FragmentSource: fSource,
+	})
+	if err != nil {
+		return fmt.Errorf("building program: %w", err)
 	}
+	defer program.Destroy()
 
 	textureUniform, err := program.Uniform("Texture")
 	if err != nil {
@@ -48,14 +48,14 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("error adding event listener: %w", err)
 	}
 
-	if err := RegisterServiceWorker(win, "/sw.js"); err != nil {
+	if err := RegisterServiceWorker(ctx, win, "/sw.js"); err != nil {
 		return fmt.Errorf("registering service worker: %w", err)
 	}
 
 	return nil
 }
 
-func RegisterServiceWorker(win *dom.Window, serviceWorkerCodePath string) error {
+func RegisterServiceWorker(ctx context.Context, win *dom.Window, serviceWorkerCodePath string) error {
 	factory, winObj := win.Driver()
 	navObj, ok := winObj.Get("navigator").ToObject()
 	if !ok {
@@ -73,16 +73,9 @@ func RegisterServiceWorker(win *dom.Window, serviceWorkerCodePath string) error
 	if !ok {
 		return fmt.Errorf("register did not return an object")
 	}
-	jsThen := driver.Bind(registerReturn, "then")
-	jsThen(
-		factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
-			fmt.Println("Service worker registered")
-			return nil
-		}),
-		factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
-			fmt.Println("Error registering service worker")
-			return nil
-		}),
-	)
+	if _, err := driver.Await(ctx, factory, registerReturn); err != nil {
+		return fmt.Errorf("awaiting service worker registration: %w", err)
+	}
+	fmt.Println("Service worker registered")
 	return nil
 }
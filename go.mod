@@ -6,5 +6,6 @@ require (
 	github.com/go-gl/mathgl v1.0.0
 	github.com/google/btree v1.0.0
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
 )
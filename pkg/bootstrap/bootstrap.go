@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/PieterD/warp/pkg/driver/remote"
 	"github.com/gorilla/mux"
 )
 
@@ -18,6 +19,9 @@ type Config struct {
 	MainPackage string
 	StaticPath  string
 	GoRoot      string
+	// Remote, if set, runs the application natively instead of building MainPackage to wasm.
+	// The page loads the remote driver shim, and Remote is called with a Factory for every page that connects.
+	Remote func(factory *remote.Factory)
 }
 
 func New(cfg Config) http.Handler {
@@ -26,9 +30,20 @@ func New(cfg Config) http.Handler {
 		mainPackage: cfg.MainPackage,
 		goRoot:      cfg.GoRoot,
 	}
+	page := indexHtml
+	if cfg.Remote != nil {
+		page = remoteIndexHtml
+		r.Path("/_remote").Handler(remote.Handler(cfg.Remote))
+		r.Path("/_remote.js").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Content-Type", "text/javascript")
+			if _, err := io.Copy(writer, strings.NewReader(remote.Shim)); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "error copying remote shim: %v\n", err)
+			}
+		})
+	}
 	r.Path("/").HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "text/html")
-		if _, err := io.Copy(writer, strings.NewReader(indexHtml)); err != nil {
+		if _, err := io.Copy(writer, strings.NewReader(page)); err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "error copying index.html: %v\n", err)
 		}
 	})
@@ -38,6 +38,20 @@ const indexHtml = `<!DOCTYPE html>
 </html>
 `
 
+// remoteIndexHtml loads the remote driver shim, which connects back to the native application.
+const remoteIndexHtml = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>Go remote</title>
+    <link rel="stylesheet" href="style.css"/>
+    <script src="_remote.js" data-socket="_remote"></script>
+</head>
+    <body></body>
+</html>
+`
+
 var tmpl = template.Must(template.New("indexhtml").Parse(indexHtml))
 
 type IndexConfig struct {
@@ -19,7 +19,7 @@ func AsCanvas(elem *Elem) *Canvas {
 	}
 }
 
-func (c *Canvas) GetContextWebgl() *gl.Context {
+func (c *Canvas) GetContextWebgl() (*gl.Context, error) {
 	return gl.NewContext(c.elem)
 }
 
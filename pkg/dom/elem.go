@@ -119,5 +119,6 @@ func (e *Elem) EventHandler(eventName string, f func(this *Elem, event *Event))
 	return func() {
 		fRemoveEventListener := driver.Bind(e.obj, "removeEventListener")
 		fRemoveEventListener(dEventName, cbFunction)
+		cbFunction.Release()
 	}
 }
@@ -0,0 +1,76 @@
+package dom
+
+import (
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+// newFakeElem creates an element that keeps track of its event listeners.
+func newFakeElem(factory *fakejs.Factory, tag string) (*Elem, map[string][]driver.Function) {
+	listeners := make(map[string][]driver.Function)
+	obj := factory.Object()
+	obj.Set("tagName", factory.String(tag))
+	obj.SetFunc("addEventListener", func(this driver.Object, args ...driver.Value) driver.Value {
+		name, _ := args[0].ToString()
+		function, _ := args[1].ToFunction()
+		listeners[name] = append(listeners[name], function)
+		return nil
+	})
+	obj.SetFunc("removeEventListener", func(this driver.Object, args ...driver.Value) driver.Value {
+		name, _ := args[0].ToString()
+		var kept []driver.Function
+		for _, function := range listeners[name] {
+			if !factory.Equal(function, args[1]) {
+				kept = append(kept, function)
+			}
+		}
+		listeners[name] = kept
+		return nil
+	})
+	return &Elem{
+		factory: factory,
+		obj:     obj,
+	}, listeners
+}
+
+func TestElemEventHandler(t *testing.T) {
+	factory := fakejs.Open()
+	elem, listeners := newFakeElem(factory, "CANVAS")
+	if tag := elem.Tag(); tag != "canvas" {
+		t.Fatalf("expected tag canvas, got: %s", tag)
+	}
+
+	var got []MouseEvent
+	deregister := elem.EventHandler("click", func(this *Elem, event *Event) {
+		me, ok := event.AsMouse()
+		if !ok {
+			t.Fatalf("expected a mouse event")
+		}
+		got = append(got, me)
+	})
+	if len(listeners["click"]) != 1 {
+		t.Fatalf("expected 1 click listener, got: %d", len(listeners["click"]))
+	}
+	if live := factory.LiveCallbacks(); live != 1 {
+		t.Fatalf("expected 1 live callback, got: %d", live)
+	}
+
+	eventObj := factory.Object()
+	eventObj.Set("type", factory.String("click"))
+	eventObj.Set("offsetX", factory.Number(3))
+	eventObj.Set("offsetY", factory.Number(4))
+	listeners["click"][0].Call(elem.obj, eventObj)
+	if len(got) != 1 || got[0].OffsetX != 3 || got[0].OffsetY != 4 {
+		t.Fatalf("expected one mouse event at (3, 4), got: %v", got)
+	}
+
+	deregister()
+	if len(listeners["click"]) != 0 {
+		t.Fatalf("expected no click listeners after deregister, got: %d", len(listeners["click"]))
+	}
+	if live := factory.LiveCallbacks(); live != 0 {
+		t.Fatalf("expected callback to be released after deregister, got %d live", live)
+	}
+}
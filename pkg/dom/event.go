@@ -33,28 +33,13 @@ type DeviceMotionEvent struct {
 }
 
 func (e *Event) AsDeviceMotionEvent() (DeviceMotionEvent, bool) {
-	o := e.obj
-	o, ok := o.Get("accelerationIncludingGravity").ToObject()
-	if !ok {
-		return DeviceMotionEvent{}, false
-	}
-	x, ok := o.Get("x").ToFloat64()
-	if !ok {
-		return DeviceMotionEvent{}, false
+	var raw struct {
+		Acceleration DeviceMotionEvent `js:"accelerationIncludingGravity"`
 	}
-	y, ok := o.Get("y").ToFloat64()
-	if !ok {
+	if err := driver.Unmarshal(e.obj, &raw); err != nil {
 		return DeviceMotionEvent{}, false
 	}
-	z, ok := o.Get("z").ToFloat64()
-	if !ok {
-		return DeviceMotionEvent{}, false
-	}
-	return DeviceMotionEvent{
-		X: x,
-		Y: y,
-		Z: z,
-	}, true
+	return raw.Acceleration, true
 }
 
 type DeviceOrientationEvent struct {
@@ -64,24 +49,11 @@ type DeviceOrientationEvent struct {
 }
 
 func (e *Event) AsDeviceOrientationEvent() (DeviceOrientationEvent, bool) {
-	o := e.obj
-	alpha, ok := o.Get("alpha").ToFloat64()
-	if !ok {
+	var doe DeviceOrientationEvent
+	if err := driver.Unmarshal(e.obj, &doe); err != nil {
 		return DeviceOrientationEvent{}, false
 	}
-	beta, ok := o.Get("beta").ToFloat64()
-	if !ok {
-		return DeviceOrientationEvent{}, false
-	}
-	gamma, ok := o.Get("gamma").ToFloat64()
-	if !ok {
-		return DeviceOrientationEvent{}, false
-	}
-	return DeviceOrientationEvent{
-		Alpha: alpha,
-		Beta:  beta,
-		Gamma: gamma,
-	}, true
+	return doe, true
 }
 
 type MouseEvent struct {
@@ -90,21 +62,10 @@ type MouseEvent struct {
 }
 
 func (e *Event) AsMouse() (MouseEvent, bool) {
-	o := e.obj
 	var me MouseEvent
-
-	offsetX, ok := o.Get("offsetX").ToFloat64()
-	if !ok {
-		return MouseEvent{}, false
-	}
-	me.OffsetX = int(offsetX)
-
-	offsetY, ok := o.Get("offsetY").ToFloat64()
-	if !ok {
+	if err := driver.Unmarshal(e.obj, &me); err != nil {
 		return MouseEvent{}, false
 	}
-	me.OffsetY = int(offsetY)
-
 	return me, true
 }
 
@@ -114,40 +75,13 @@ type KeyboardEvent struct {
 	Repeat     bool
 	ShiftKey   bool
 	MetaKey    bool
-	ControlKey bool
+	ControlKey bool `js:"ctrlKey"`
 	AltKey     bool
 }
 
 func (e *Event) AsKeyboard() (KeyboardEvent, bool) {
-	o := e.obj
 	var ke KeyboardEvent
-	var ok bool
-	ke.Key, ok = o.Get("key").ToString()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.Code, ok = o.Get("code").ToString()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.Repeat, ok = o.Get("repeat").ToBoolean()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.ShiftKey, ok = o.Get("shiftKey").ToBoolean()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.MetaKey, ok = o.Get("metaKey").ToBoolean()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.ControlKey, ok = o.Get("ctrlKey").ToBoolean()
-	if !ok {
-		return KeyboardEvent{}, false
-	}
-	ke.AltKey, ok = o.Get("altKey").ToBoolean()
-	if !ok {
+	if err := driver.Unmarshal(e.obj, &ke); err != nil {
 		return KeyboardEvent{}, false
 	}
 	return ke, true
@@ -181,5 +115,6 @@ func AddEventListener(elem Driver, eventName string, f func(this driver.Value, e
 	return func() {
 		fRemoveEventListener := driver.Bind(object, "removeEventListener")
 		fRemoveEventListener(jsEventName, jsCallback)
+		jsCallback.Release()
 	}, nil
 }
@@ -0,0 +1,33 @@
+package dom
+
+import (
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestEventAsKeyboard(t *testing.T) {
+	factory := fakejs.Open()
+	obj := factory.Object()
+	obj.Set("key", factory.String("a"))
+	obj.Set("code", factory.String("KeyA"))
+	obj.Set("repeat", factory.Boolean(false))
+	obj.Set("shiftKey", factory.Boolean(false))
+	obj.Set("metaKey", factory.Boolean(false))
+	obj.Set("ctrlKey", factory.Boolean(true))
+	obj.Set("altKey", factory.Boolean(false))
+	event := &Event{
+		factory: factory,
+		obj:     obj,
+	}
+	ke, ok := event.AsKeyboard()
+	if !ok {
+		t.Fatalf("expected a keyboard event")
+	}
+	if want := (KeyboardEvent{Key: "a", Code: "KeyA", ControlKey: true}); ke != want {
+		t.Fatalf("expected %+v, got: %+v", want, ke)
+	}
+	if _, ok := event.AsMouse(); ok {
+		t.Fatalf("expected keyboard event not to be a mouse event")
+	}
+}
@@ -41,6 +41,7 @@ func (w *Window) Animate(ctx context.Context, f func(ctx context.Context, millis
 		}
 		if err := f(ctx, millis); err != nil {
 			fmt.Printf("[ERROR] animation callback: %v\n", err)
+			cb.Release()
 			return nil
 		}
 		fRequestAnimationFrame(cb)
@@ -0,0 +1,43 @@
+package dom
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestWindowAnimate(t *testing.T) {
+	factory := fakejs.Open()
+	var frames []driver.Function
+	winObj := factory.Object()
+	winObj.SetFunc("requestAnimationFrame", func(this driver.Object, args ...driver.Value) driver.Value {
+		function, _ := args[0].ToFunction()
+		frames = append(frames, function)
+		return nil
+	})
+	win := &Window{
+		factory: factory,
+		obj:     winObj,
+	}
+
+	count := 0
+	win.Animate(context.Background(), func(ctx context.Context, millis float64) error {
+		count++
+		if count == 3 {
+			return fmt.Errorf("done")
+		}
+		return nil
+	})
+	for i := 0; i < len(frames); i++ {
+		frames[i].Call(nil, factory.Number(float64(i)*16))
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 frames, got: %d", count)
+	}
+	if live := factory.LiveCallbacks(); live != 0 {
+		t.Fatalf("expected animation callback to be released, got %d live", live)
+	}
+}
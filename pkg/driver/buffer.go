@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"fmt"
+)
+
+// ArrayType is the type of a JavaScript typed array.
+type ArrayType int
+
+const (
+	Int8Array ArrayType = iota + 1
+	Uint8Array
+	Uint8ClampedArray
+	Int16Array
+	Uint16Array
+	Int32Array
+	Uint32Array
+	Float32Array
+	Float64Array
+)
+
+// String returns the name of the typed array's constructor.
+func (t ArrayType) String() string {
+	switch t {
+	case Int8Array:
+		return "Int8Array"
+	case Uint8Array:
+		return "Uint8Array"
+	case Uint8ClampedArray:
+		return "Uint8ClampedArray"
+	case Int16Array:
+		return "Int16Array"
+	case Uint16Array:
+		return "Uint16Array"
+	case Int32Array:
+		return "Int32Array"
+	case Uint32Array:
+		return "Uint32Array"
+	case Float32Array:
+		return "Float32Array"
+	case Float64Array:
+		return "Float64Array"
+	default:
+		return fmt.Sprintf("ArrayType(%d)", int(t))
+	}
+}
+
+// BytesPerElement returns the size of a single element of the typed array.
+func (t ArrayType) BytesPerElement() int {
+	switch t {
+	case Int8Array, Uint8Array, Uint8ClampedArray:
+		return 1
+	case Int16Array, Uint16Array:
+		return 2
+	case Int32Array, Uint32Array, Float32Array:
+		return 4
+	case Float64Array:
+		return 8
+	default:
+		panic(fmt.Errorf("invalid ArrayType: %v", t))
+	}
+}
+
+// CheckView panics if a view of the given type, byte offset and length does not fit in a buffer,
+// or is not aligned to the element size.
+// The buffer itself may start at bufferOffset into its backing ArrayBuffer.
+// It is meant for Buffer implementations.
+func CheckView(bufferOffset, bufferSize int, t ArrayType, byteOffset, length int) {
+	size := t.BytesPerElement()
+	if (bufferOffset+byteOffset)%size != 0 {
+		panic(fmt.Errorf("%s byte offset %d is not a multiple of %d", t, bufferOffset+byteOffset, size))
+	}
+	if byteOffset < 0 || length < 0 || byteOffset+length*size > bufferSize {
+		panic(fmt.Errorf("%s view of %d elements at byte offset %d does not fit in buffer of %d bytes", t, length, byteOffset, bufferSize))
+	}
+}
@@ -0,0 +1,390 @@
+// Package drivertest checks that a driver.Factory behaves like wasmjs, which defines the semantics of the driver.
+//
+// Besides the values it creates itself, the checks only rely on the global Object, Array, ArrayBuffer and
+// typed array constructors, which every driver (including an empty fakejs) provides.
+package drivertest
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// Config describes the ways a driver is allowed to differ.
+type Config struct {
+	// AsyncCallbacks is set for drivers whose Go callbacks run after the JavaScript call invoking them has returned,
+	// like remote. The return values of their callbacks are not checked.
+	AsyncCallbacks bool
+}
+
+// Run runs every check as a subtest of t.
+func Run(t *testing.T, factory driver.Factory, cfg Config) {
+	for _, check := range checks {
+		check := check
+		t.Run(check.name, func(t *testing.T) {
+			check.run(t, factory, cfg)
+		})
+	}
+}
+
+type check struct {
+	name string
+	run  func(t *testing.T, factory driver.Factory, cfg Config)
+}
+
+var checks = []check{
+	{"Primitives", checkPrimitives},
+	{"Equal", checkEqual},
+	{"Object", checkObject},
+	{"Null", checkNull},
+	{"Array", checkArray},
+	{"Function", checkFunction},
+	{"Callback", checkCallback},
+	{"Exception", checkException},
+	{"Buffer", checkBuffer},
+	{"TypedArrayConversion", checkTypedArrayConversion},
+}
+
+// conversions describes which of the To* methods of a value succeed.
+type conversions struct {
+	boolean, number, str, object, function bool
+}
+
+func conversionsOf(v driver.Value) conversions {
+	var c conversions
+	_, c.boolean = v.ToBoolean()
+	_, c.number = v.ToFloat64()
+	_, c.str = v.ToString()
+	_, c.object = v.ToObject()
+	_, c.function = v.ToFunction()
+	return c
+}
+
+func checkPrimitives(t *testing.T, factory driver.Factory, cfg Config) {
+	for _, test := range []struct {
+		name        string
+		value       driver.Value
+		typeOf      string
+		isUndefined bool
+		isNull      bool
+		conversions conversions
+	}{
+		{"undefined", factory.Undefined(), "undefined", true, false, conversions{}},
+		{"null", factory.Null(), "object", false, true, conversions{}},
+		{"boolean", factory.Boolean(false), "boolean", false, false, conversions{boolean: true}},
+		{"number", factory.Number(0), "number", false, false, conversions{number: true}},
+		{"string", factory.String(""), "string", false, false, conversions{str: true}},
+	} {
+		if got := test.value.TypeOf(); got != test.typeOf {
+			t.Errorf("%s: expected typeof %s, got: %s", test.name, test.typeOf, got)
+		}
+		if got := test.value.IsUndefined(); got != test.isUndefined {
+			t.Errorf("%s: expected IsUndefined %t, got: %t", test.name, test.isUndefined, got)
+		}
+		if got := test.value.IsNull(); got != test.isNull {
+			t.Errorf("%s: expected IsNull %t, got: %t", test.name, test.isNull, got)
+		}
+		if got := conversionsOf(test.value); got != test.conversions {
+			t.Errorf("%s: expected conversions %+v, got: %+v", test.name, test.conversions, got)
+		}
+	}
+	if b, _ := factory.Boolean(true).ToBoolean(); !b {
+		t.Errorf("expected true")
+	}
+	if f, _ := factory.Number(-1.5).ToFloat64(); f != -1.5 {
+		t.Errorf("expected -1.5, got: %v", f)
+	}
+	if s, _ := factory.String("héllo").ToString(); s != "héllo" {
+		t.Errorf("expected héllo, got: %q", s)
+	}
+	if f, _ := factory.Number(math.Inf(-1)).ToFloat64(); !math.IsInf(f, -1) {
+		t.Errorf("expected -Inf, got: %v", f)
+	}
+}
+
+func checkEqual(t *testing.T, factory driver.Factory, cfg Config) {
+	global := factory.Global()
+	objectConstructor := global.Get("Object")
+	for _, test := range []struct {
+		name   string
+		v1, v2 driver.Value
+		equal  bool
+	}{
+		{"same numbers", factory.Number(1), factory.Number(1), true},
+		{"different numbers", factory.Number(1), factory.Number(2), false},
+		{"NaN", factory.Number(math.NaN()), factory.Number(math.NaN()), false},
+		{"zeroes", factory.Number(0), factory.Number(math.Copysign(0, -1)), true},
+		{"number and string", factory.Number(0), factory.String("0"), false},
+		{"same strings", factory.String("a"), factory.String("a"), true},
+		{"booleans", factory.Boolean(true), factory.Boolean(true), true},
+		{"undefined and null", factory.Undefined(), factory.Null(), false},
+		{"undefineds", factory.Undefined(), factory.Undefined(), true},
+		{"nil and null", nil, factory.Null(), true},
+		{"same object", global, factory.Global(), true},
+		{"same object from Get", global.Get("window"), global.Get("window"), true},
+		{"different objects", factory.Array(), factory.Array(), false},
+		{"same function", objectConstructor, global.Get("Object"), true},
+		{"object and function", global, objectConstructor, false},
+	} {
+		if got := factory.Equal(test.v1, test.v2); got != test.equal {
+			t.Errorf("%s: expected Equal to return %t", test.name, test.equal)
+		}
+	}
+}
+
+func checkObject(t *testing.T, factory driver.Factory, cfg Config) {
+	global := factory.Global()
+	if global.TypeOf() != "object" {
+		t.Errorf("expected the global object to have typeof object, got: %s", global.TypeOf())
+	}
+	if got := conversionsOf(global); got != (conversions{object: true}) {
+		t.Errorf("expected an object to only convert to an object, got: %+v", got)
+	}
+	obj := newObject(t, factory)
+	if !obj.Get("missing").IsUndefined() {
+		t.Errorf("expected a missing property to be undefined")
+	}
+	if obj.Has("missing") {
+		t.Errorf("expected Has to be false for a missing property")
+	}
+	obj.Set("b", factory.Number(2))
+	obj.Set("a", factory.Undefined())
+	if !factory.Equal(obj.Get("b"), factory.Number(2)) {
+		t.Errorf("expected b to be 2, got: %v", obj.Get("b"))
+	}
+	if !obj.Has("a") {
+		t.Errorf("expected Has to be true for a property set to undefined")
+	}
+	if want, got := []string{"b", "a"}, obj.Keys(); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected keys %v, got: %v", want, got)
+	}
+	obj.Delete("b")
+	if obj.Has("b") || !obj.Get("b").IsUndefined() {
+		t.Errorf("expected b to be deleted")
+	}
+	objectConstructor, _ := global.Get("Object").ToFunction()
+	arrayConstructor, _ := global.Get("Array").ToFunction()
+	if !obj.InstanceOf(objectConstructor) || obj.InstanceOf(arrayConstructor) {
+		t.Errorf("expected a new Object to be an instance of Object and not of Array")
+	}
+}
+
+// checkNull checks that a nil driver.Value is passed to JavaScript as null.
+func checkNull(t *testing.T, factory driver.Factory, cfg Config) {
+	obj := newObject(t, factory)
+	obj.Set("nil", nil)
+	if !obj.Get("nil").IsNull() || !obj.Has("nil") {
+		t.Errorf("expected a property set to nil to be null")
+	}
+	array := factory.Array(nil)
+	if !array.Index(0).IsNull() {
+		t.Errorf("expected a nil array element to be null, got: %v", array.Index(0))
+	}
+	arrayConstructor, _ := factory.Global().Get("Array").ToFunction()
+	constructed := arrayConstructor.New(factory.Undefined(), nil)
+	if !constructed.Index(0).IsUndefined() || !constructed.Index(1).IsNull() {
+		t.Errorf("expected undefined and null constructor arguments to stay apart, got: %v, %v", constructed.Index(0), constructed.Index(1))
+	}
+}
+
+func checkArray(t *testing.T, factory driver.Factory, cfg Config) {
+	array := factory.Array(factory.Number(3))
+	if length, _ := array.Get("length").ToFloat64(); length != 1 {
+		t.Errorf("expected Array with a single number to have length 1, got: %v", length)
+	}
+	if !factory.Equal(array.Index(0), factory.Number(3)) {
+		t.Errorf("expected element 0 to be 3, got: %v", array.Index(0))
+	}
+	array.SetIndex(0, factory.String("x"))
+	if !factory.Equal(array.Get("0"), factory.String("x")) {
+		t.Errorf("expected SetIndex and Get to use the same property, got: %v", array.Get("0"))
+	}
+	if !array.Index(5).IsUndefined() {
+		t.Errorf("expected an element out of range to be undefined")
+	}
+	if want, got := []string{"0"}, array.Keys(); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected the keys of an array to be its indices, got: %v", got)
+	}
+	array.SetIndex(2, factory.Number(5))
+	if length, _ := array.Get("length").ToFloat64(); length != 3 {
+		t.Errorf("expected setting an element past the end to grow the array to length 3, got: %v", length)
+	}
+	arrayConstructor, _ := factory.Global().Get("Array").ToFunction()
+	if !array.InstanceOf(arrayConstructor) {
+		t.Errorf("expected an array to be an instance of Array")
+	}
+	sized := arrayConstructor.New(factory.Number(3))
+	if length, _ := sized.Get("length").ToFloat64(); length != 3 {
+		t.Errorf("expected the Array constructor with a single number to create an array of that length, got: %v", length)
+	}
+}
+
+func checkFunction(t *testing.T, factory driver.Factory, cfg Config) {
+	constructor := factory.Global().Get("Object")
+	if constructor.TypeOf() != "function" {
+		t.Errorf("expected a constructor to have typeof function, got: %s", constructor.TypeOf())
+	}
+	if got := conversionsOf(constructor); got != (conversions{function: true}) {
+		t.Errorf("expected a function to only convert to a function, got: %+v", got)
+	}
+	objectConstructor, _ := constructor.ToFunction()
+	obj := objectConstructor.New()
+	if obj.TypeOf() != "object" {
+		t.Errorf("expected New to create an object, got: %s", obj.TypeOf())
+	}
+	// Calling Object with a nil this must not panic; it then behaves like new Object().
+	rv := objectConstructor.Call(nil)
+	if _, ok := rv.ToObject(); !ok {
+		t.Errorf("expected calling Object to return an object, got: %v", rv)
+	}
+}
+
+func checkCallback(t *testing.T, factory driver.Factory, cfg Config) {
+	live := factory.LiveCallbacks()
+	calls := make(chan []driver.Value, 1)
+	callback := factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
+		calls <- args
+		return factory.String("returned")
+	})
+	if got := factory.LiveCallbacks(); got != live+1 {
+		t.Errorf("expected %d live callbacks, got: %d", live+1, got)
+	}
+	if callback.TypeOf() != "function" {
+		t.Errorf("expected a callback to have typeof function, got: %s", callback.TypeOf())
+	}
+	rv := callback.Call(nil, factory.Number(1), nil, factory.Undefined())
+	var args []driver.Value
+	if cfg.AsyncCallbacks {
+		select {
+		case args = <-calls:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("callback was not called")
+		}
+	} else {
+		select {
+		case args = <-calls:
+		default:
+			t.Fatalf("callback was not called before Call returned")
+		}
+		if !factory.Equal(rv, factory.String("returned")) {
+			t.Errorf("expected the callback's return value, got: %v", rv)
+		}
+	}
+	if len(args) != 3 {
+		t.Fatalf("expected 3 arguments, got: %v", args)
+	}
+	if !factory.Equal(args[0], factory.Number(1)) || !args[1].IsNull() || !args[2].IsUndefined() {
+		t.Errorf("expected arguments 1, null, undefined, got: %v", args)
+	}
+	callback.Release()
+	callback.Release()
+	if got := factory.LiveCallbacks(); got != live {
+		t.Errorf("expected %d live callbacks after release, got: %d", live, got)
+	}
+}
+
+func checkException(t *testing.T, factory driver.Factory, cfg Config) {
+	arrayBufferConstructor, _ := factory.Global().Get("ArrayBuffer").ToFunction()
+	_, err := arrayBufferConstructor.NewErr(factory.Number(-1))
+	var jsErr *driver.Error
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("expected an *Error for an invalid ArrayBuffer length, got: %v", err)
+	}
+	if jsErr.Name != "RangeError" {
+		t.Errorf("expected a RangeError, got: %v", jsErr)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected New to panic when the constructor throws")
+			}
+		}()
+		arrayBufferConstructor.New(factory.Number(-1))
+	}()
+}
+
+func checkBuffer(t *testing.T, factory driver.Factory, cfg Config) {
+	buffer := factory.Buffer(8)
+	if buffer.Size() != 8 {
+		t.Errorf("expected size 8, got: %d", buffer.Size())
+	}
+	if n := buffer.Put([]byte{1, 0, 2, 0, 0, 0, 128, 63, 99}); n != 8 {
+		t.Errorf("expected to put 8 bytes, put: %d", n)
+	}
+	if got, _ := buffer.AsUint16Array().Index(1).ToFloat64(); got != 2 {
+		t.Errorf("expected second uint16 to be 2, got: %v", got)
+	}
+	if got, _ := buffer.AsFloat32Array().Index(1).ToFloat64(); got != 1 {
+		t.Errorf("expected second float32 to be 1, got: %v", got)
+	}
+	view := buffer.View(driver.Uint8Array, 2, 2)
+	if length, _ := view.Get("length").ToFloat64(); length != 2 {
+		t.Errorf("expected view length 2, got: %v", length)
+	}
+	slice := buffer.Slice(4, 4)
+	slice.Put([]byte{7})
+	data := make([]byte, 16)
+	if n := buffer.Get(data); n != 8 {
+		t.Errorf("expected to get 8 bytes, got: %d", n)
+	}
+	if want := []byte{1, 0, 2, 0, 7, 0, 128, 63}; !reflect.DeepEqual(data[:8], want) {
+		t.Errorf("expected %v, got: %v", want, data[:8])
+	}
+	uint8ArrayConstructor, _ := factory.Global().Get("Uint8Array").ToFunction()
+	if !buffer.AsUint8Array().InstanceOf(uint8ArrayConstructor) {
+		t.Errorf("expected AsUint8Array to return a Uint8Array")
+	}
+}
+
+func newObject(t *testing.T, factory driver.Factory) driver.Object {
+	t.Helper()
+	constructor, ok := factory.Global().Get("Object").ToFunction()
+	if !ok {
+		t.Fatalf("Object constructor is missing")
+	}
+	return constructor.New()
+}
+
+// typedArrayConversions are the numbers stored into a typed array, and what they read back as.
+var typedArrayConversions = []struct {
+	typ       driver.ArrayType
+	set, want float64
+}{
+	{driver.Int8Array, 200, -56},
+	{driver.Int8Array, -129, 127},
+	{driver.Int8Array, -1.9, -1},
+	{driver.Int8Array, math.NaN(), 0},
+	{driver.Uint8Array, 256, 0},
+	{driver.Uint8Array, -1, 255},
+	{driver.Uint8Array, 300.7, 44},
+	{driver.Uint8Array, math.Inf(1), 0},
+	{driver.Uint8ClampedArray, 2.5, 2},
+	{driver.Uint8ClampedArray, 3.5, 4},
+	{driver.Uint8ClampedArray, 254.5, 254},
+	{driver.Uint8ClampedArray, 300, 255},
+	{driver.Uint8ClampedArray, -5, 0},
+	{driver.Uint8ClampedArray, math.NaN(), 0},
+	{driver.Int16Array, 32768, -32768},
+	{driver.Uint16Array, 65537, 1},
+	{driver.Uint16Array, -1, 65535},
+	{driver.Int32Array, 1 << 31, -(1 << 31)},
+	{driver.Int32Array, 1<<32 + 1, 1},
+	{driver.Uint32Array, -1, 1<<32 - 1},
+	{driver.Uint32Array, 1e10, 1410065408},
+	{driver.Uint32Array, math.Inf(-1), 0},
+}
+
+func checkTypedArrayConversion(t *testing.T, factory driver.Factory, cfg Config) {
+	buffer := factory.Buffer(8)
+	for _, conversion := range typedArrayConversions {
+		array := buffer.View(conversion.typ, 0, 1)
+		array.SetIndex(0, factory.Number(conversion.set))
+		if got, _ := array.Index(0).ToFloat64(); got != conversion.want {
+			t.Errorf("expected %v stored in a %v to be %v, got: %v", conversion.set, conversion.typ, conversion.want, got)
+		}
+	}
+}
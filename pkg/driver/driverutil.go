@@ -15,16 +15,27 @@ func Bind(o Object, methodName string) func(args ...Value) Value {
 	}
 }
 
+// BindErr is like Bind, but returns an error if the method is missing,
+// and the returned function returns thrown exceptions as errors.
+func BindErr(o Object, methodName string) (func(args ...Value) (Value, error), error) {
+	got := o.Get(methodName)
+	function, ok := got.ToFunction()
+	if !ok {
+		return nil, fmt.Errorf("method %s is not a function", methodName)
+	}
+	return func(args ...Value) (Value, error) {
+		return function.CallErr(o, args...)
+	}, nil
+}
+
 func IndexableToSlice(factory Factory, o Object) []Value {
 	numChildren, ok := o.Get("length").ToFloat64()
 	if !ok {
 		return nil
 	}
 	var values []Value
-	fIndex := Bind(o, "item")
 	for i := 0; i < int(numChildren); i++ {
-		dValue := fIndex(factory.Number(float64(i)))
-		values = append(values, dValue)
+		values = append(values, o.Index(i))
 	}
 	return values
 }
@@ -0,0 +1,46 @@
+package driver
+
+import (
+	"fmt"
+)
+
+// Error is a JavaScript exception, caught by Function.CallErr or Function.NewErr.
+type Error struct {
+	// Value is the thrown value, usually an Error object.
+	Value   Value
+	Name    string
+	Message string
+	Stack   string
+}
+
+// NewError describes a thrown value.
+// Error objects have their name, message and stack read; strings are used as the message.
+func NewError(thrown Value) *Error {
+	e := &Error{
+		Value: thrown,
+	}
+	if s, ok := thrown.ToString(); ok {
+		e.Message = s
+		return e
+	}
+	if f, ok := thrown.ToFloat64(); ok {
+		e.Message = fmt.Sprintf("%v", f)
+		return e
+	}
+	o, ok := thrown.ToObject()
+	if !ok {
+		e.Message = "non-object exception"
+		return e
+	}
+	e.Name, _ = o.Get("name").ToString()
+	e.Message, _ = o.Get("message").ToString()
+	e.Stack, _ = o.Get("stack").ToString()
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("javascript exception: %s", e.Message)
+	}
+	return fmt.Sprintf("javascript exception: %s: %s", e.Name, e.Message)
+}
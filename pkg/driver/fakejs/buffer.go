@@ -0,0 +1,245 @@
+package fakejs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+type typedArrayKind struct {
+	typ  driver.ArrayType
+	name string
+	size int
+	get  func(b []byte) float64
+	set  func(b []byte, f float64)
+}
+
+var typedArrayKinds = []*typedArrayKind{
+	newTypedArrayKind(driver.Int8Array,
+		func(b []byte) float64 { return float64(int8(b[0])) },
+		func(b []byte, f float64) { b[0] = byte(toUintN(f, 8)) }),
+	newTypedArrayKind(driver.Uint8Array,
+		func(b []byte) float64 { return float64(b[0]) },
+		func(b []byte, f float64) { b[0] = byte(toUintN(f, 8)) }),
+	newTypedArrayKind(driver.Uint8ClampedArray,
+		func(b []byte) float64 { return float64(b[0]) },
+		func(b []byte, f float64) { b[0] = toUint8Clamp(f) }),
+	newTypedArrayKind(driver.Int16Array,
+		func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint16(b, uint16(toUintN(f, 16))) }),
+	newTypedArrayKind(driver.Uint16Array,
+		func(b []byte) float64 { return float64(binary.LittleEndian.Uint16(b)) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint16(b, uint16(toUintN(f, 16))) }),
+	newTypedArrayKind(driver.Int32Array,
+		func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint32(b, uint32(toUintN(f, 32))) }),
+	newTypedArrayKind(driver.Uint32Array,
+		func(b []byte) float64 { return float64(binary.LittleEndian.Uint32(b)) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint32(b, uint32(toUintN(f, 32))) }),
+	newTypedArrayKind(driver.Float32Array,
+		func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint32(b, math.Float32bits(float32(f))) }),
+	newTypedArrayKind(driver.Float64Array,
+		func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) },
+		func(b []byte, f float64) { binary.LittleEndian.PutUint64(b, math.Float64bits(f)) }),
+}
+
+// toUintN converts f to an integer of the given number of bits the way JavaScript's ToUint8, ToUint16 and ToUint32 do:
+// it is truncated and wrapped modulo 2^bits, and NaN and infinities become 0.
+// The signed conversions have the same bits.
+func toUintN(f float64, bits uint) uint64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	modulo := float64(uint64(1) << bits)
+	f = math.Mod(math.Trunc(f), modulo)
+	if f < 0 {
+		f += modulo
+	}
+	return uint64(f)
+}
+
+// toUint8Clamp converts f the way JavaScript's ToUint8Clamp does: it is clamped to 0-255,
+// and rounded with halves to even. NaN becomes 0.
+func toUint8Clamp(f float64) byte {
+	if math.IsNaN(f) {
+		return 0
+	}
+	return byte(math.RoundToEven(math.Max(0, math.Min(255, f))))
+}
+
+func newTypedArrayKind(typ driver.ArrayType, get func(b []byte) float64, set func(b []byte, f float64)) *typedArrayKind {
+	return &typedArrayKind{
+		typ:  typ,
+		name: typ.String(),
+		size: typ.BytesPerElement(),
+		get:  get,
+		set:  set,
+	}
+}
+
+func typedArrayKindByType(typ driver.ArrayType) *typedArrayKind {
+	for _, kind := range typedArrayKinds {
+		if kind.typ == typ {
+			return kind
+		}
+	}
+	panic(fmt.Errorf("invalid ArrayType: %v", typ))
+}
+
+// newTypedArray implements the typed array constructors.
+// It accepts either a length, or an ArrayBuffer with an optional byte offset and length.
+func (f *Factory) newTypedArray(kind *typedArrayKind, args ...driver.Value) *Object {
+	if len(args) == 0 {
+		args = []driver.Value{f.Number(0)}
+	}
+	var arrayBuffer *Object
+	var data []byte
+	var offset int
+	switch arg := args[0].(type) {
+	case numberValue:
+		arrayBuffer = f.newArrayBuffer(f.Number(arg.v * float64(kind.size)))
+		data = arrayBuffer.data
+	case *Object:
+		if arg.class != "ArrayBuffer" {
+			panic(fmt.Errorf("%s constructor does not accept %v", kind.name, arg))
+		}
+		arrayBuffer = arg
+		byteOffset := 0
+		if len(args) > 1 {
+			byteOffset = toInt(args[1])
+		}
+		length := (len(arrayBuffer.data) - byteOffset) / kind.size
+		if len(args) > 2 {
+			length = toInt(args[2])
+		}
+		driver.CheckView(0, len(arrayBuffer.data), kind.typ, byteOffset, length)
+		data = arrayBuffer.data[byteOffset : byteOffset+length*kind.size]
+		offset = byteOffset
+	default:
+		panic(fmt.Errorf("%s constructor does not accept %v", kind.name, args[0]))
+	}
+	obj := f.newObject(kind.name)
+	obj.kind = kind
+	obj.data = data
+	obj.offset = offset
+	obj.Set("buffer", arrayBuffer)
+	return obj
+}
+
+func (o *Object) getTypedArray(key string) (driver.Value, bool) {
+	kind := o.kind
+	switch key {
+	case "length":
+		return o.factory.Number(float64(len(o.data) / kind.size)), true
+	case "byteLength":
+		return o.factory.Number(float64(len(o.data))), true
+	case "byteOffset":
+		return o.factory.Number(float64(o.offset)), true
+	case "BYTES_PER_ELEMENT":
+		return o.factory.Number(float64(kind.size)), true
+	}
+	index, err := strconv.Atoi(key)
+	if err != nil {
+		return nil, false
+	}
+	if index < 0 || index*kind.size >= len(o.data) {
+		return o.factory.Undefined(), true
+	}
+	return o.factory.Number(kind.get(o.data[index*kind.size:])), true
+}
+
+// setTypedArray stores a number at a numeric index.
+// Like in JavaScript, writes to out of range indices are ignored.
+func (o *Object) setTypedArray(key string, value driver.Value) bool {
+	index, err := strconv.Atoi(key)
+	if err != nil {
+		return false
+	}
+	if index < 0 || index*o.kind.size >= len(o.data) {
+		return true
+	}
+	f, _ := value.ToFloat64()
+	o.kind.set(o.data[index*o.kind.size:], f)
+	return true
+}
+
+func toInt(v driver.Value) int {
+	f, ok := v.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("expected a number: %v", v))
+	}
+	return int(f)
+}
+
+// Bytes returns the bytes viewed by a typed array, or the backing store of an ArrayBuffer.
+// The returned slice shares memory with the value.
+func Bytes(v driver.Value) (data []byte, ok bool) {
+	obj, ok := v.(*Object)
+	if !ok || (obj.kind == nil && obj.class != "ArrayBuffer") {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+type buffer struct {
+	factory *Factory
+	array   *Object
+}
+
+func newBuffer(factory *Factory, size int) buffer {
+	return buffer{
+		factory: factory,
+		array:   factory.newTypedArray(typedArrayKindByType(driver.Uint8Array), factory.Number(float64(size))),
+	}
+}
+
+func (b buffer) Size() int {
+	return len(b.array.data)
+}
+
+func (b buffer) Put(data []byte) int {
+	return copy(b.array.data, data)
+}
+
+func (b buffer) Get(data []byte) int {
+	return copy(data, b.array.data)
+}
+
+func (b buffer) Slice(byteOffset, byteLength int) driver.Buffer {
+	return buffer{
+		factory: b.factory,
+		array:   b.View(driver.Uint8Array, byteOffset, byteLength).(*Object),
+	}
+}
+
+func (b buffer) View(t driver.ArrayType, byteOffset, length int) driver.Object {
+	driver.CheckView(b.array.offset, len(b.array.data), t, byteOffset, length)
+	return b.factory.newTypedArray(
+		typedArrayKindByType(t),
+		b.array.Get("buffer"),
+		b.factory.Number(float64(b.array.offset+byteOffset)),
+		b.factory.Number(float64(length)),
+	)
+}
+
+func (b buffer) As(t driver.ArrayType) driver.Object {
+	return b.View(t, 0, len(b.array.data)/t.BytesPerElement())
+}
+
+func (b buffer) AsUint8Array() driver.Object {
+	return b.As(driver.Uint8Array)
+}
+
+func (b buffer) AsUint16Array() driver.Object {
+	return b.As(driver.Uint16Array)
+}
+
+func (b buffer) AsFloat32Array() driver.Object {
+	return b.As(driver.Float32Array)
+}
+
+var _ driver.Buffer = buffer{}
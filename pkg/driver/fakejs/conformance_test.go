@@ -0,0 +1,12 @@
+package fakejs_test
+
+import (
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver/drivertest"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, fakejs.Open(), drivertest.Config{})
+}
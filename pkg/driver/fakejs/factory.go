@@ -0,0 +1,270 @@
+package fakejs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// Factory is a pure Go implementation of driver.Factory.
+// Every call to a Function created by it is recorded in its call log.
+type Factory struct {
+	global *Object
+
+	lock          sync.Mutex
+	calls         []Call
+	logs          []string
+	liveCallbacks int
+}
+
+// Call is a single recorded invocation of a Function.
+type Call struct {
+	Name   string
+	This   driver.Object
+	Args   []driver.Value
+	Return driver.Value
+}
+
+func (f *Factory) Equal(v1, v2 driver.Value) (equal bool) {
+	if v1 == nil {
+		v1 = f.Null()
+	}
+	if v2 == nil {
+		v2 = f.Null()
+	}
+	switch t1 := v1.(type) {
+	case undefinedValue:
+		_, ok := v2.(undefinedValue)
+		return ok
+	case nullValue:
+		_, ok := v2.(nullValue)
+		return ok
+	case booleanValue:
+		t2, ok := v2.(booleanValue)
+		return ok && t1.v == t2.v
+	case numberValue:
+		t2, ok := v2.(numberValue)
+		return ok && t1.v == t2.v
+	case stringValue:
+		t2, ok := v2.(stringValue)
+		return ok && t1.v == t2.v
+	case *Object:
+		t2, ok := v2.(*Object)
+		return ok && t1 == t2
+	case *Function:
+		t2, ok := v2.(*Function)
+		return ok && t1 == t2
+	default:
+		panic(fmt.Errorf("value was not our type: %T", v1))
+	}
+}
+
+func (f *Factory) Global() driver.Object {
+	return f.global
+}
+
+func (f *Factory) Undefined() driver.Value {
+	return undefinedValue{}
+}
+
+func (f *Factory) Null() driver.Value {
+	return nullValue{}
+}
+
+func (f *Factory) Boolean(t bool) driver.Value {
+	return booleanValue{v: t}
+}
+
+func (f *Factory) Number(n float64) driver.Value {
+	return numberValue{v: n}
+}
+
+func (f *Factory) String(s string) driver.Value {
+	return stringValue{v: s}
+}
+
+func (f *Factory) Function(fn func(this driver.Object, args ...driver.Value) driver.Value) driver.Function {
+	function := f.Func("", fn)
+	function.callback = true
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.liveCallbacks++
+	return function
+}
+
+func (f *Factory) Buffer(size int) driver.Buffer {
+	return newBuffer(f, size)
+}
+
+func (f *Factory) Array(values ...driver.Value) driver.Object {
+	return f.newArray(values...)
+}
+
+func (f *Factory) LiveCallbacks() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.liveCallbacks
+}
+
+// Object creates a new, empty object.
+func (f *Factory) Object() *Object {
+	return f.newObject("Object")
+}
+
+// Func creates a new function with the given name.
+// The name is used to identify calls to it in the call log.
+func (f *Factory) Func(name string, fn func(this driver.Object, args ...driver.Value) driver.Value) *Function {
+	return &Function{
+		factory: f,
+		name:    name,
+		fn:      fn,
+	}
+}
+
+// Throw throws an Error object with the given name and message.
+// It is meant to be called from inside a scripted function, and does not return.
+// Like in wasmjs, the exception panics through Call and New, and is returned by CallErr and NewErr.
+func (f *Factory) Throw(name, message string) {
+	obj := f.newObject(name)
+	obj.Set("name", f.String(name))
+	obj.Set("message", f.String(message))
+	obj.Set("stack", f.String(fmt.Sprintf("%s: %s\n    at fakejs", name, message)))
+	f.ThrowValue(obj)
+}
+
+// ThrowValue throws an arbitrary value.
+func (f *Factory) ThrowValue(value driver.Value) {
+	panic(exception{
+		value: value,
+	})
+}
+
+// Calls returns a copy of the call log.
+func (f *Factory) Calls() []Call {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns all logged calls to functions with the given name.
+func (f *Factory) CallsTo(name string) (calls []Call) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, call := range f.calls {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// ResetCalls clears the call log.
+func (f *Factory) ResetCalls() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = nil
+}
+
+// Logs returns everything written to console.log.
+func (f *Factory) Logs() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string(nil), f.logs...)
+}
+
+func (f *Factory) record(call Call) (index int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, call)
+	return len(f.calls) - 1
+}
+
+func (f *Factory) recordReturn(index int, rv driver.Value) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if index >= len(f.calls) {
+		// The call log was reset during the call.
+		return
+	}
+	f.calls[index].Return = rv
+}
+
+func (f *Factory) consoleLog(this driver.Object, args ...driver.Value) driver.Value {
+	var parts []string
+	for _, arg := range args {
+		if s, ok := arg.ToString(); ok {
+			parts = append(parts, s)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%v", arg))
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.logs = append(f.logs, strings.Join(parts, " "))
+	return f.Undefined()
+}
+
+func (f *Factory) newObject(class string) *Object {
+	return &Object{
+		factory: f,
+		class:   class,
+		props:   make(map[string]driver.Value),
+	}
+}
+
+// newArrayConstructed implements the Array constructor, which treats a single number as the length.
+func (f *Factory) newArrayConstructed(args ...driver.Value) *Object {
+	if len(args) == 1 {
+		if length, ok := args[0].ToFloat64(); ok {
+			obj := f.newObject("Array")
+			obj.Set("length", f.Number(length))
+			return obj
+		}
+	}
+	return f.newArray(args...)
+}
+
+func (f *Factory) newArray(values ...driver.Value) *Object {
+	obj := f.newObject("Array")
+	for i, value := range values {
+		obj.Set(fmt.Sprintf("%d", i), value)
+	}
+	obj.Set("length", f.Number(float64(len(values))))
+	return obj
+}
+
+func (f *Factory) newArrayBuffer(args ...driver.Value) *Object {
+	size := 0
+	if len(args) > 0 {
+		fSize, ok := args[0].ToFloat64()
+		if !ok {
+			panic(fmt.Errorf("ArrayBuffer size must be a number: %v", args[0]))
+		}
+		if fSize < 0 || fSize != float64(int(fSize)) {
+			f.Throw("RangeError", "Invalid array buffer length")
+		}
+		size = int(fSize)
+	}
+	obj := f.newObject("ArrayBuffer")
+	obj.data = make([]byte, size)
+	return obj
+}
+
+// constructor creates a function that, when invoked with New, returns the object built by build.
+// When build is nil, New returns an empty object of the given class.
+func (f *Factory) constructor(class string, build func(args ...driver.Value) *Object) *Function {
+	return f.Func(class, func(this driver.Object, args ...driver.Value) driver.Value {
+		if build == nil {
+			// Called without new, Object creates a new object.
+			if this == nil {
+				return f.newObject(class)
+			}
+			return this
+		}
+		return build(args...)
+	})
+}
+
+var _ driver.Factory = &Factory{}
@@ -0,0 +1,29 @@
+package fakejs
+
+import (
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// Open creates a new in-memory driver.
+// The global object comes with a console, a window property pointing back to the global object,
+// and the Object, Array, ArrayBuffer and typed array constructors.
+// Everything else must be scripted by the caller using Object and Func.
+func Open() (factory *Factory) {
+	factory = &Factory{}
+	global := factory.Object()
+	factory.global = global
+	global.Set("window", global)
+	global.Set("Object", factory.constructor("Object", nil))
+	global.Set("Array", factory.constructor("Array", factory.newArrayConstructed))
+	global.Set("ArrayBuffer", factory.constructor("ArrayBuffer", factory.newArrayBuffer))
+	for _, kind := range typedArrayKinds {
+		kind := kind
+		global.Set(kind.name, factory.constructor(kind.name, func(args ...driver.Value) *Object {
+			return factory.newTypedArray(kind, args...)
+		}))
+	}
+	console := factory.Object()
+	console.SetFunc("log", factory.consoleLog)
+	global.Set("console", console)
+	return factory
+}
@@ -0,0 +1,208 @@
+package fakejs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+func TestCallLog(t *testing.T) {
+	factory := Open()
+	obj := factory.Object()
+	obj.SetFunc("add", func(this driver.Object, args ...driver.Value) driver.Value {
+		a, _ := args[0].ToFloat64()
+		b, _ := args[1].ToFloat64()
+		return factory.Number(a + b)
+	})
+	factory.Global().Set("calc", obj)
+
+	calc, ok := factory.Global().Get("calc").ToObject()
+	if !ok {
+		t.Fatalf("calc is not an object")
+	}
+	rv := driver.Bind(calc, "add")(factory.Number(1), factory.Number(2))
+	if f, ok := rv.ToFloat64(); !ok || f != 3 {
+		t.Fatalf("expected 3, got: %v", rv)
+	}
+	calls := factory.CallsTo("add")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 call, got: %d", len(calls))
+	}
+	if !factory.Equal(calls[0].This, calc) {
+		t.Fatalf("expected this to be calc, got: %v", calls[0].This)
+	}
+	if !factory.Equal(calls[0].Return, rv) {
+		t.Fatalf("expected logged return %v, got: %v", rv, calls[0].Return)
+	}
+	factory.ResetCalls()
+	if calls := factory.Calls(); len(calls) != 0 {
+		t.Fatalf("expected empty call log after reset, got: %v", calls)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	factory := Open()
+	buffer := factory.Buffer(8)
+	if n := buffer.Put([]byte{1, 0, 2, 0, 0, 0, 128, 63}); n != 8 {
+		t.Fatalf("expected to put 8 bytes, put: %d", n)
+	}
+	u16 := buffer.AsUint16Array()
+	if got := toInt(u16.Get("length")); got != 4 {
+		t.Fatalf("expected Uint16Array length 4, got: %d", got)
+	}
+	if got := toInt(u16.Get("1")); got != 2 {
+		t.Fatalf("expected second uint16 to be 2, got: %d", got)
+	}
+	f32 := buffer.AsFloat32Array()
+	if got, _ := f32.Get("1").ToFloat64(); got != 1.0 {
+		t.Fatalf("expected second float32 to be 1.0, got: %v", got)
+	}
+	data, ok := Bytes(buffer.AsUint8Array())
+	if !ok {
+		t.Fatalf("expected Uint8Array to have bytes")
+	}
+	data[0] = 5
+	got := make([]byte, 2)
+	buffer.Get(got)
+	if want := []byte{5, 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected views to share memory, want %v got %v", want, got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	factory := Open()
+	obj := factory.Object()
+	for _, test := range []struct {
+		v1, v2 driver.Value
+		want   bool
+	}{
+		{factory.Undefined(), factory.Undefined(), true},
+		{factory.Undefined(), factory.Null(), false},
+		{nil, factory.Null(), true},
+		{factory.Number(1), factory.Number(1), true},
+		{factory.Number(1), factory.String("1"), false},
+		{factory.String("a"), factory.String("a"), true},
+		{obj, obj, true},
+		{obj, factory.Object(), false},
+	} {
+		if got := factory.Equal(test.v1, test.v2); got != test.want {
+			t.Errorf("Equal(%v, %v): want %t, got %t", test.v1, test.v2, test.want, got)
+		}
+	}
+}
+
+func TestThrow(t *testing.T) {
+	factory := Open()
+	obj := factory.Object()
+	obj.SetFunc("fail", func(this driver.Object, args ...driver.Value) driver.Value {
+		factory.Throw("TypeError", "invalid enum")
+		return nil
+	})
+	fail, err := driver.BindErr(obj, "fail")
+	if err != nil {
+		t.Fatalf("binding fail: %v", err)
+	}
+	_, err = fail()
+	jsErr, ok := err.(*driver.Error)
+	if !ok {
+		t.Fatalf("expected *driver.Error, got: %T %v", err, err)
+	}
+	if jsErr.Name != "TypeError" || jsErr.Message != "invalid enum" || jsErr.Stack == "" {
+		t.Fatalf("unexpected error contents: %#v", jsErr)
+	}
+
+	if _, err := driver.BindErr(obj, "missing"); err == nil {
+		t.Fatalf("expected error binding missing method")
+	}
+
+	constructor := factory.Func("Thrower", func(this driver.Object, args ...driver.Value) driver.Value {
+		factory.ThrowValue(factory.String("plain string"))
+		return nil
+	})
+	if _, err := constructor.NewErr(); err == nil || err.(*driver.Error).Message != "plain string" {
+		t.Fatalf("expected plain string exception, got: %v", err)
+	}
+}
+
+func TestBufferViews(t *testing.T) {
+	factory := Open()
+	buffer := factory.Buffer(16)
+	buffer.Put([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f})
+	if got := toInt(buffer.As(driver.Int32Array).Get("0")); got != -1 {
+		t.Fatalf("expected first int32 to be -1, got: %d", got)
+	}
+	if got, _ := buffer.View(driver.Float64Array, 8, 1).Get("0").ToFloat64(); got != 1.0 {
+		t.Fatalf("expected float64 at byte offset 8 to be 1.0, got: %v", got)
+	}
+
+	slice := buffer.Slice(4, 8)
+	if size := slice.Size(); size != 8 {
+		t.Fatalf("expected slice size 8, got: %d", size)
+	}
+	slice.Put([]byte{1, 0, 0, 0, 2})
+	if got := toInt(buffer.As(driver.Uint32Array).Get("1")); got != 1 {
+		t.Fatalf("expected slice to share memory with its buffer, got: %d", got)
+	}
+	view := slice.View(driver.Uint32Array, 4, 1)
+	if got := toInt(view.Get("byteOffset")); got != 8 {
+		t.Fatalf("expected view byte offset 8 into the backing ArrayBuffer, got: %d", got)
+	}
+	if got := toInt(view.Get("0")); got != 2 {
+		t.Fatalf("expected view to start at the slice offset, got: %d", got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected misaligned view to panic")
+			}
+		}()
+		slice.View(driver.Float64Array, 0, 1)
+	}()
+}
+
+func TestIntrospection(t *testing.T) {
+	factory := Open()
+	obj := factory.Object()
+	obj.Set("b", factory.Undefined())
+	obj.Set("a", factory.Number(1))
+	if want, got := []string{"b", "a"}, obj.Keys(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected keys %v, got: %v", want, got)
+	}
+	if !obj.Has("b") || obj.Has("c") {
+		t.Fatalf("expected Has to distinguish undefined properties from missing ones")
+	}
+	obj.Delete("b")
+	if obj.Has("b") || !reflect.DeepEqual(obj.Keys(), []string{"a"}) {
+		t.Fatalf("expected b to be deleted, keys: %v", obj.Keys())
+	}
+
+	for _, test := range []struct {
+		value driver.Value
+		want  string
+	}{
+		{factory.Undefined(), "undefined"},
+		{factory.Null(), "object"},
+		{factory.Boolean(true), "boolean"},
+		{factory.Number(1), "number"},
+		{factory.String(""), "string"},
+		{obj, "object"},
+		{factory.Func("f", nil), "function"},
+	} {
+		if got := test.value.TypeOf(); got != test.want {
+			t.Fatalf("expected typeof %v to be %s, got: %s", test.value, test.want, got)
+		}
+	}
+
+	f32 := factory.Buffer(8).AsFloat32Array()
+	f32.SetIndex(1, factory.Number(1.5))
+	if got, _ := f32.Index(1).ToFloat64(); got != 1.5 {
+		t.Fatalf("expected index 1 to be 1.5, got: %v", got)
+	}
+	float32Array, _ := factory.Global().Get("Float32Array").ToFunction()
+	uint8Array, _ := factory.Global().Get("Uint8Array").ToFunction()
+	if !f32.InstanceOf(float32Array) || f32.InstanceOf(uint8Array) {
+		t.Fatalf("expected Float32Array to be an instance of Float32Array only")
+	}
+}
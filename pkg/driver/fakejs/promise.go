@@ -0,0 +1,77 @@
+package fakejs
+
+import (
+	"sync"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// Promise creates a Promise-like object with a then method, and the functions to settle it.
+// Callbacks registered with then are called when the promise settles,
+// or immediately if it already has; only the first call to resolve or reject has any effect.
+// resolve and reject may be called from any goroutine.
+func (f *Factory) Promise() (promise *Object, resolve func(value driver.Value), reject func(reason driver.Value)) {
+	var (
+		lock       sync.Mutex
+		settled    bool
+		rejected   bool
+		result     driver.Value
+		onResolves []driver.Function
+		onRejects  []driver.Function
+	)
+	notify := func(functions []driver.Function, value driver.Value) {
+		for _, function := range functions {
+			if function != nil {
+				function.Call(nil, value)
+			}
+		}
+	}
+	settle := func(isRejected bool, value driver.Value) {
+		lock.Lock()
+		if settled {
+			lock.Unlock()
+			return
+		}
+		settled = true
+		rejected = isRejected
+		result = value
+		callbacks := onResolves
+		if rejected {
+			callbacks = onRejects
+		}
+		onResolves, onRejects = nil, nil
+		lock.Unlock()
+		notify(callbacks, value)
+	}
+	promise = f.newObject("Promise")
+	promise.SetFunc("then", func(this driver.Object, args ...driver.Value) driver.Value {
+		var onResolve, onReject driver.Function
+		if len(args) > 0 {
+			onResolve, _ = args[0].ToFunction()
+		}
+		if len(args) > 1 {
+			onReject, _ = args[1].ToFunction()
+		}
+		lock.Lock()
+		if !settled {
+			onResolves = append(onResolves, onResolve)
+			onRejects = append(onRejects, onReject)
+			lock.Unlock()
+			return nil
+		}
+		lock.Unlock()
+		if rejected {
+			notify([]driver.Function{onReject}, result)
+		} else {
+			notify([]driver.Function{onResolve}, result)
+		}
+		return nil
+	})
+	resolve = func(value driver.Value) {
+		settle(false, value)
+	}
+	reject = func(reason driver.Value) {
+		settle(true, reason)
+	}
+	return promise, resolve, reject
+}
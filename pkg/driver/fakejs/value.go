@@ -0,0 +1,402 @@
+package fakejs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+type undefinedValue struct {
+	empty
+}
+
+func (v undefinedValue) IsUndefined() bool {
+	return true
+}
+
+func (v undefinedValue) String() string {
+	return "undefined"
+}
+
+func (v undefinedValue) TypeOf() string {
+	return "undefined"
+}
+
+var _ driver.Value = undefinedValue{}
+
+type nullValue struct {
+	empty
+}
+
+func (v nullValue) IsNull() bool {
+	return true
+}
+
+func (v nullValue) String() string {
+	return "null"
+}
+
+func (v nullValue) TypeOf() string {
+	return "object"
+}
+
+var _ driver.Value = nullValue{}
+
+type booleanValue struct {
+	empty
+	v bool
+}
+
+func (v booleanValue) ToBoolean() (bool, bool) {
+	return v.v, true
+}
+
+func (v booleanValue) String() string {
+	return strconv.FormatBool(v.v)
+}
+
+func (v booleanValue) TypeOf() string {
+	return "boolean"
+}
+
+var _ driver.Value = booleanValue{}
+
+type numberValue struct {
+	empty
+	v float64
+}
+
+func (v numberValue) ToFloat64() (float64, bool) {
+	return v.v, true
+}
+
+func (v numberValue) String() string {
+	return strconv.FormatFloat(v.v, 'g', -1, 64)
+}
+
+func (v numberValue) TypeOf() string {
+	return "number"
+}
+
+var _ driver.Value = numberValue{}
+
+type stringValue struct {
+	empty
+	v string
+}
+
+func (v stringValue) ToString() (string, bool) {
+	return v.v, true
+}
+
+func (v stringValue) String() string {
+	return strconv.Quote(v.v)
+}
+
+func (v stringValue) TypeOf() string {
+	return "string"
+}
+
+var _ driver.Value = stringValue{}
+
+// Object is a scriptable JavaScript object.
+type Object struct {
+	empty
+	factory *Factory
+	class   string
+	props   map[string]driver.Value
+	// keys holds the names of props in insertion order.
+	keys []string
+	// data is the backing store of an ArrayBuffer, or the viewed bytes of a typed array.
+	data []byte
+	// kind and offset are only set for typed arrays.
+	kind   *typedArrayKind
+	offset int
+	// length is the length of an Array. Like in JavaScript, it is not one of the keys,
+	// and grows when an element is set past the end.
+	length int
+}
+
+func (o *Object) TypeOf() string {
+	return "object"
+}
+
+func (o *Object) ToObject() (driver.Object, bool) {
+	return o, true
+}
+
+func (o *Object) Get(key string) driver.Value {
+	if o.kind != nil {
+		if v, ok := o.getTypedArray(key); ok {
+			return v
+		}
+	}
+	if o.class == "ArrayBuffer" && key == "byteLength" {
+		return o.factory.Number(float64(len(o.data)))
+	}
+	if o.class == "Array" && key == "length" {
+		return o.factory.Number(float64(o.length))
+	}
+	v, ok := o.props[key]
+	if !ok {
+		return o.factory.Undefined()
+	}
+	return v
+}
+
+func (o *Object) Set(key string, value driver.Value) {
+	if value == nil {
+		value = o.factory.Null()
+	}
+	if o.kind != nil && o.setTypedArray(key, value) {
+		return
+	}
+	if o.class == "Array" {
+		if key == "length" {
+			o.setLength(value)
+			return
+		}
+		if i, err := strconv.Atoi(key); err == nil && i >= o.length && strconv.Itoa(i) == key {
+			o.length = i + 1
+		}
+	}
+	if _, ok := o.props[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.props[key] = value
+}
+
+func (o *Object) Has(key string) bool {
+	if o.kind != nil {
+		if v, ok := o.getTypedArray(key); ok {
+			return !v.IsUndefined()
+		}
+	}
+	if o.class == "ArrayBuffer" && key == "byteLength" {
+		return true
+	}
+	if o.class == "Array" && key == "length" {
+		return true
+	}
+	_, ok := o.props[key]
+	return ok
+}
+
+// setLength sets the length of an Array, removing the elements past the new end.
+func (o *Object) setLength(value driver.Value) {
+	f, ok := value.ToFloat64()
+	if !ok || f < 0 || f != float64(int(f)) {
+		o.factory.Throw("RangeError", "Invalid array length")
+	}
+	length := int(f)
+	for i := length; i < o.length; i++ {
+		o.Delete(strconv.Itoa(i))
+	}
+	o.length = length
+}
+
+func (o *Object) Delete(key string) {
+	if _, ok := o.props[key]; !ok {
+		return
+	}
+	delete(o.props, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the names of all properties set on the object, in insertion order.
+func (o *Object) Keys() []string {
+	return append([]string(nil), o.keys...)
+}
+
+func (o *Object) Index(i int) driver.Value {
+	return o.Get(strconv.Itoa(i))
+}
+
+func (o *Object) SetIndex(i int, value driver.Value) {
+	o.Set(strconv.Itoa(i), value)
+}
+
+// InstanceOf returns true if the object was created by a constructor with the same name.
+// Every object is an instance of Object.
+func (o *Object) InstanceOf(constructor driver.Function) bool {
+	f, ok := constructor.(*Function)
+	if !ok {
+		return false
+	}
+	return f.name == o.class || f.name == "Object"
+}
+
+// SetFunc creates a function with the given name, and stores it as a property under the same name.
+func (o *Object) SetFunc(name string, fn func(this driver.Object, args ...driver.Value) driver.Value) *Function {
+	function := o.factory.Func(name, fn)
+	o.Set(name, function)
+	return function
+}
+
+// Class returns the name of the constructor that created the object.
+func (o *Object) Class() string {
+	return o.class
+}
+
+func (o *Object) String() string {
+	return fmt.Sprintf("[object %s]", o.class)
+}
+
+var _ driver.Object = &Object{}
+
+// Function is a scriptable JavaScript function, backed by a Go function.
+type Function struct {
+	empty
+	factory *Factory
+	name    string
+	fn      func(this driver.Object, args ...driver.Value) driver.Value
+	// callback is set for functions created by Factory.Function, which have to be released.
+	callback bool
+	released bool
+}
+
+func (f *Function) TypeOf() string {
+	return "function"
+}
+
+func (f *Function) ToFunction() (driver.Function, bool) {
+	return f, true
+}
+
+// Name returns the name of the function, as used in the call log.
+func (f *Function) Name() string {
+	return f.name
+}
+
+func (f *Function) New(args ...driver.Value) driver.Object {
+	this := f.factory.newObject(f.name)
+	rv := f.invoke(this, args)
+	if obj, ok := rv.(*Object); ok {
+		return obj
+	}
+	return this
+}
+
+func (f *Function) Call(this driver.Object, args ...driver.Value) driver.Value {
+	return f.invoke(this, args)
+}
+
+func (f *Function) NewErr(args ...driver.Value) (obj driver.Object, err error) {
+	defer recoverException(&err)
+	return f.New(args...), nil
+}
+
+func (f *Function) CallErr(this driver.Object, args ...driver.Value) (rv driver.Value, err error) {
+	defer recoverException(&err)
+	return f.Call(this, args...), nil
+}
+
+// Release marks a callback as released; calling it afterwards panics, like it would in wasmjs.
+func (f *Function) Release() {
+	if !f.callback {
+		return
+	}
+	f.factory.lock.Lock()
+	defer f.factory.lock.Unlock()
+	if f.released {
+		return
+	}
+	f.released = true
+	f.factory.liveCallbacks--
+}
+
+// Released returns true if Release was called on a callback.
+func (f *Function) Released() bool {
+	f.factory.lock.Lock()
+	defer f.factory.lock.Unlock()
+	return f.released
+}
+
+func (f *Function) invoke(this driver.Object, args []driver.Value) driver.Value {
+	if f.Released() {
+		panic(fmt.Errorf("call to released function"))
+	}
+	args = append([]driver.Value(nil), args...)
+	for i, arg := range args {
+		if arg == nil {
+			args[i] = f.factory.Null()
+		}
+	}
+	index := f.factory.record(Call{
+		Name: f.name,
+		This: this,
+		Args: args,
+	})
+	var rv driver.Value
+	if f.fn != nil {
+		rv = f.fn(this, args...)
+	}
+	if rv == nil {
+		rv = f.factory.Null()
+	}
+	f.factory.recordReturn(index, rv)
+	return rv
+}
+
+func (f *Function) String() string {
+	return fmt.Sprintf("function %s()", f.name)
+}
+
+var _ driver.Function = &Function{}
+
+// exception is the panic value used to unwind a Go stack when a scripted function throws.
+type exception struct {
+	value driver.Value
+}
+
+func (e exception) Error() string {
+	return driver.NewError(e.value).Error()
+}
+
+func recoverException(err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	e, ok := p.(exception)
+	if !ok {
+		panic(p)
+	}
+	*err = driver.NewError(e.value)
+}
+
+type empty struct{}
+
+func (e empty) IsUndefined() bool {
+	return false
+}
+
+func (e empty) IsNull() bool {
+	return false
+}
+
+func (e empty) ToBoolean() (bool, bool) {
+	return false, false
+}
+
+func (e empty) ToFloat64() (float64, bool) {
+	return 0, false
+}
+
+func (e empty) ToString() (string, bool) {
+	return "", false
+}
+
+func (e empty) ToObject() (driver.Object, bool) {
+	return nil, false
+}
+
+func (e empty) ToFunction() (driver.Function, bool) {
+	return nil, false
+}
@@ -0,0 +1,279 @@
+package driver
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+/*
+Unmarshal and Marshal convert between JavaScript values and Go values.
+
+	bool                       boolean
+	int*, uint*, float*        number (integers are truncated, out of range values are an error)
+	string                     string
+	struct                     object, using the exported fields
+	slice, array               array-like object, using length and indices
+	map[string]T               object (Marshal only)
+	pointer                    the value it points to, or null/undefined for nil
+	driver.Value               any value, as is
+
+Struct fields are named by their js tag, or by their name with the first letter in lower case.
+A tag of "-" skips the field.
+The optional flag (`js:"offsetX,optional"`) makes Unmarshal accept a missing, undefined or null property,
+and makes Marshal leave out a field with a zero value.
+*/
+
+var typeValue = reflect.TypeOf((*Value)(nil)).Elem()
+
+// Unmarshal stores the JavaScript value in the Go value pointed to by ptr.
+func Unmarshal(value Value, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("unmarshal target must be a non-nil pointer: %T", ptr)
+	}
+	return unmarshal(value, rv.Elem())
+}
+
+func unmarshal(value Value, rv reflect.Value) error {
+	if rv.Type() == typeValue {
+		rv.Set(reflect.ValueOf(&value).Elem())
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if value.IsUndefined() || value.IsNull() {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		elem := reflect.New(rv.Type().Elem())
+		if err := unmarshal(value, elem.Elem()); err != nil {
+			return err
+		}
+		rv.Set(elem)
+		return nil
+	case reflect.Bool:
+		b, ok := value.ToBoolean()
+		if !ok {
+			return fmt.Errorf("expected boolean, got %s", TypeName(value))
+		}
+		rv.SetBool(b)
+		return nil
+	case reflect.String:
+		s, ok := value.ToString()
+		if !ok {
+			return fmt.Errorf("expected string, got %s", TypeName(value))
+		}
+		rv.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := value.ToFloat64()
+		if !ok {
+			return fmt.Errorf("expected number, got %s", TypeName(value))
+		}
+		// Converting a float that does not fit in an int64 is implementation-defined, so check it first.
+		if math.IsNaN(f) || f < -(1<<63) || f >= 1<<63 || rv.OverflowInt(int64(f)) {
+			return fmt.Errorf("number %v does not fit in %s", f, rv.Type())
+		}
+		rv.SetInt(int64(f))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f, ok := value.ToFloat64()
+		if !ok {
+			return fmt.Errorf("expected number, got %s", TypeName(value))
+		}
+		if math.IsNaN(f) || f < 0 || f >= 1<<64 || rv.OverflowUint(uint64(f)) {
+			return fmt.Errorf("number %v does not fit in %s", f, rv.Type())
+		}
+		rv.SetUint(uint64(f))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := value.ToFloat64()
+		if !ok {
+			return fmt.Errorf("expected number, got %s", TypeName(value))
+		}
+		rv.SetFloat(f)
+		return nil
+	case reflect.Struct:
+		o, ok := value.ToObject()
+		if !ok {
+			return fmt.Errorf("expected object, got %s", TypeName(value))
+		}
+		return unmarshalStruct(o, rv)
+	case reflect.Slice, reflect.Array:
+		o, ok := value.ToObject()
+		if !ok {
+			return fmt.Errorf("expected array, got %s", TypeName(value))
+		}
+		fLength, ok := o.Get("length").ToFloat64()
+		if !ok {
+			return fmt.Errorf("expected array, got object without length")
+		}
+		length := int(fLength)
+		if rv.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rv.Type(), length, length))
+		} else if length != rv.Len() {
+			return fmt.Errorf("expected array of length %d, got %d", rv.Len(), length)
+		}
+		for i := 0; i < length; i++ {
+			if err := unmarshal(o.Index(i), rv.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %s", rv.Type())
+	}
+}
+
+func unmarshalStruct(o Object, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, optional, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		value := o.Get(name)
+		if optional && (value.IsUndefined() || value.IsNull()) {
+			continue
+		}
+		if err := unmarshal(value, rv.Field(i)); err != nil {
+			return fmt.Errorf("field %s (%s): %w", field.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// Marshal converts a Go value to a JavaScript value.
+func Marshal(factory Factory, v interface{}) (Value, error) {
+	if v == nil {
+		return factory.Null(), nil
+	}
+	return marshal(factory, reflect.ValueOf(v))
+}
+
+func marshal(factory Factory, rv reflect.Value) (Value, error) {
+	if rv.Type().Implements(typeValue) {
+		if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+			return factory.Null(), nil
+		}
+		return rv.Interface().(Value), nil
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return factory.Null(), nil
+		}
+		return marshal(factory, rv.Elem())
+	case reflect.Bool:
+		return factory.Boolean(rv.Bool()), nil
+	case reflect.String:
+		return factory.String(rv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return factory.Number(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return factory.Number(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return factory.Number(rv.Float()), nil
+	case reflect.Struct:
+		o, err := newObject(factory)
+		if err != nil {
+			return nil, err
+		}
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, optional, ok := fieldName(field)
+			if !ok {
+				continue
+			}
+			fieldValue := rv.Field(i)
+			if optional && fieldValue.IsZero() {
+				continue
+			}
+			value, err := marshal(factory, fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("field %s (%s): %w", field.Name, name, err)
+			}
+			o.Set(name, value)
+		}
+		return o, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s", rv.Type().Key())
+		}
+		if rv.IsNil() {
+			return factory.Null(), nil
+		}
+		o, err := newObject(factory)
+		if err != nil {
+			return nil, err
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			value, err := marshal(factory, iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", key, err)
+			}
+			o.Set(key, value)
+		}
+		return o, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return factory.Null(), nil
+		}
+		var values []Value
+		for i := 0; i < rv.Len(); i++ {
+			value, err := marshal(factory, rv.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			values = append(values, value)
+		}
+		return factory.Array(values...), nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", rv.Type())
+	}
+}
+
+func newObject(factory Factory) (Object, error) {
+	constructor, ok := factory.Global().Get("Object").ToFunction()
+	if !ok {
+		return nil, fmt.Errorf("Object constructor is missing")
+	}
+	return constructor.New(), nil
+}
+
+// fieldName returns the JavaScript property name of a struct field.
+func fieldName(field reflect.StructField) (name string, optional bool, ok bool) {
+	if field.PkgPath != "" {
+		return "", false, false
+	}
+	tag := field.Tag.Get("js")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, flag := range parts[1:] {
+		if flag == "optional" {
+			optional = true
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name[:1]) + field.Name[1:]
+	}
+	return name, optional, true
+}
+
+// TypeName returns a description of the type of a value.
+// It is like Value.TypeOf, except that it returns "null" for null and nil.
+func TypeName(value Value) string {
+	if value == nil || value.IsNull() {
+		return "null"
+	}
+	return value.TypeOf()
+}
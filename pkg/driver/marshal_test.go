@@ -0,0 +1,90 @@
+package driver_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+type testPoint struct {
+	X float32 `js:"x"`
+	Y float32 `js:"y"`
+}
+
+type testShape struct {
+	Name    string
+	Closed  bool `js:"isClosed"`
+	Count   uint8
+	Points  []testPoint
+	Origin  *testPoint `js:",optional"`
+	Comment string     `js:"comment,optional"`
+	Raw     driver.Value
+	Skipped int `js:"-"`
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	factory := fakejs.Open()
+	in := testShape{
+		Name:   "triangle",
+		Closed: true,
+		Count:  3,
+		Points: []testPoint{{0, 0}, {1, 0}, {0.5, 1}},
+		Raw:    factory.String("raw"),
+	}
+	value, err := driver.Marshal(factory, in)
+	if err != nil {
+		t.Fatalf("marshalling: %v", err)
+	}
+	obj, _ := value.(*fakejs.Object)
+	if want, got := []string{"name", "isClosed", "count", "points", "raw"}, obj.Keys(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected keys %v, got: %v", want, got)
+	}
+
+	var out testShape
+	if err := driver.Unmarshal(value, &out); err != nil {
+		t.Fatalf("unmarshalling: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch:\nwant %#v\ngot  %#v", in, out)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	factory := fakejs.Open()
+	obj := factory.Object()
+	obj.Set("count", factory.Number(300))
+	var shape struct {
+		Count uint8
+	}
+	if err := driver.Unmarshal(obj, &shape); err == nil {
+		t.Fatalf("expected error for number out of range")
+	}
+
+	for _, f := range []float64{1e19, -1e20, 1 << 63, math.NaN(), math.Inf(1)} {
+		var i int64
+		if err := driver.Unmarshal(factory.Number(f), &i); err == nil {
+			t.Fatalf("expected error for %v in an int64, got: %d", f, i)
+		}
+	}
+	for _, f := range []float64{1e20, 1 << 64, -1, math.NaN(), math.Inf(1)} {
+		var u uint64
+		if err := driver.Unmarshal(factory.Number(f), &u); err == nil {
+			t.Fatalf("expected error for %v in a uint64, got: %d", f, u)
+		}
+	}
+	var min int64
+	if err := driver.Unmarshal(factory.Number(-(1 << 63)), &min); err != nil || min != math.MinInt64 {
+		t.Fatalf("expected the smallest int64 to fit, got: %d %v", min, err)
+	}
+
+	var point testPoint
+	if err := driver.Unmarshal(factory.Object(), &point); err == nil {
+		t.Fatalf("expected error for missing required field")
+	}
+	if err := driver.Unmarshal(obj, point); err == nil {
+		t.Fatalf("expected error for non-pointer target")
+	}
+}
@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+)
+
+// Await blocks until the Promise-like object resolves or rejects, or until the context is done.
+// A resolved promise returns its value, a rejected promise returns an *Error wrapping the rejection reason.
+//
+// Await must not be called from a callback invoked by JavaScript (like an event handler),
+// because the promise can only settle after the callback returns.
+func Await(ctx context.Context, factory Factory, promise Object) (Value, error) {
+	then, ok := promise.Get("then").ToFunction()
+	if !ok {
+		return nil, fmt.Errorf("object is not a promise: then is not a function")
+	}
+	type settlement struct {
+		value Value
+		err   error
+	}
+	settled := make(chan settlement, 1)
+	settle := func(s settlement) {
+		select {
+		case settled <- s:
+		default:
+		}
+	}
+	// Both callbacks are released as soon as either is called; the promise will not call them again.
+	// If the context is done first, they stay alive until the promise settles.
+	var onResolve, onReject Function
+	release := func() {
+		onResolve.Release()
+		onReject.Release()
+	}
+	onResolve = factory.Function(func(this Object, args ...Value) Value {
+		settle(settlement{value: firstArg(factory, args)})
+		release()
+		return nil
+	})
+	onReject = factory.Function(func(this Object, args ...Value) Value {
+		settle(settlement{err: fmt.Errorf("promise rejected: %w", NewError(firstArg(factory, args)))})
+		release()
+		return nil
+	})
+	if _, err := then.CallErr(promise, onResolve, onReject); err != nil {
+		release()
+		return nil, fmt.Errorf("calling then: %w", err)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case s := <-settled:
+		return s.value, s.err
+	}
+}
+
+func firstArg(factory Factory, args []Value) Value {
+	if len(args) == 0 {
+		return factory.Undefined()
+	}
+	return args[0]
+}
@@ -0,0 +1,52 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestAwait(t *testing.T) {
+	factory := fakejs.Open()
+
+	promise, resolve, _ := factory.Promise()
+	go func() {
+		time.Sleep(time.Millisecond)
+		resolve(factory.String("done"))
+	}()
+	value, err := driver.Await(context.Background(), factory, promise)
+	if err != nil {
+		t.Fatalf("awaiting resolved promise: %v", err)
+	}
+	if s, _ := value.ToString(); s != "done" {
+		t.Fatalf("expected resolved value done, got: %v", value)
+	}
+
+	promise, _, reject := factory.Promise()
+	reject(factory.String("denied"))
+	_, err = driver.Await(context.Background(), factory, promise)
+	var jsErr *driver.Error
+	if !errors.As(err, &jsErr) || jsErr.Message != "denied" {
+		t.Fatalf("expected rejection reason denied, got: %v", err)
+	}
+
+	promise, _, _ = factory.Promise()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := driver.Await(ctx, factory, promise); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+
+	if _, err := driver.Await(context.Background(), factory, factory.Object()); err == nil {
+		t.Fatalf("expected error awaiting a non-promise")
+	}
+
+	// Only the callbacks of the promise that never settled are still alive.
+	if live := factory.LiveCallbacks(); live != 2 {
+		t.Fatalf("expected 2 live callbacks, got: %d", live)
+	}
+}
@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// buffer is a Uint8Array in the shim.
+// Put and Get each copy the bytes over the connection in a single request.
+type buffer struct {
+	factory *Factory
+	array   objectValue
+	// byteOffset is the offset of array into its ArrayBuffer.
+	byteOffset int
+	size       int
+}
+
+func (b buffer) Size() int {
+	return b.size
+}
+
+func (b buffer) Put(data []byte) int {
+	if len(data) > b.size {
+		data = data[:b.size]
+	}
+	b.factory.roundTrip(message{Op: opPut, Target: b.array.wire(), Data: data}, b.array)
+	return len(data)
+}
+
+func (b buffer) Get(data []byte) int {
+	n := len(data)
+	if n > b.size {
+		n = b.size
+	}
+	rv := b.factory.roundTrip(message{Op: opRead, Target: b.array.wire(), Index: n}, b.array)
+	return copy(data, rv.Data)
+}
+
+func (b buffer) Slice(byteOffset, byteLength int) driver.Buffer {
+	return buffer{
+		factory:    b.factory,
+		array:      b.View(driver.Uint8Array, byteOffset, byteLength).(objectValue),
+		byteOffset: b.byteOffset + byteOffset,
+		size:       byteLength,
+	}
+}
+
+func (b buffer) View(t driver.ArrayType, byteOffset, length int) driver.Object {
+	driver.CheckView(b.byteOffset, b.size, t, byteOffset, length)
+	f := b.factory
+	args := []driver.Value{f.Number(float64(byteOffset)), f.Number(float64(length))}
+	rv := f.roundTrip(message{Op: opView, Target: b.array.wire(), Key: t.String(), Args: f.encodeAll(args)}, b.array)
+	return f.decode(rv.Value).(objectValue)
+}
+
+func (b buffer) As(t driver.ArrayType) driver.Object {
+	return b.View(t, 0, b.size/t.BytesPerElement())
+}
+
+func (b buffer) AsUint8Array() driver.Object {
+	return b.As(driver.Uint8Array)
+}
+
+func (b buffer) AsUint16Array() driver.Object {
+	return b.As(driver.Uint16Array)
+}
+
+func (b buffer) AsFloat32Array() driver.Object {
+	return b.As(driver.Float32Array)
+}
+
+var _ driver.Buffer = buffer{}
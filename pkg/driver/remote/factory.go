@@ -0,0 +1,406 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/gorilla/websocket"
+)
+
+// Factory is a driver.Factory whose values live in a JavaScript shim at the other end of a WebSocket.
+// It may be used from multiple goroutines; JavaScript executes their requests one at a time.
+type Factory struct {
+	conn      *websocket.Conn
+	writeLock sync.Mutex
+	global    objectValue
+	queue     *callbackQueue
+	done      chan struct{}
+
+	lock         sync.Mutex
+	err          error
+	lastID       int
+	pending      map[int]chan message
+	free         []int
+	lastCallback int
+	callbacks    map[int]func(this driver.Object, args ...driver.Value) driver.Value
+}
+
+// Connect starts talking to the shim on the other end of conn.
+func Connect(conn *websocket.Conn) *Factory {
+	f := &Factory{
+		conn:      conn,
+		queue:     newCallbackQueue(),
+		done:      make(chan struct{}),
+		pending:   make(map[int]chan message),
+		callbacks: make(map[int]func(this driver.Object, args ...driver.Value) driver.Value),
+	}
+	f.global = objectValue{h: &handle{factory: f, id: 0}}
+	go f.readLoop()
+	go f.dispatchLoop()
+	return f
+}
+
+// Close closes the connection. Any operation after that panics.
+func (f *Factory) Close() error {
+	f.fail(fmt.Errorf("factory closed"))
+	return nil
+}
+
+// Done returns a channel that is closed when the connection is lost or closed.
+func (f *Factory) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns the reason the connection was lost, or nil if it is still open.
+func (f *Factory) Err() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.err
+}
+
+func (f *Factory) fail(err error) {
+	f.lock.Lock()
+	if f.err != nil {
+		f.lock.Unlock()
+		return
+	}
+	f.err = err
+	close(f.done)
+	f.lock.Unlock()
+	_ = f.conn.Close()
+}
+
+func (f *Factory) Equal(v1, v2 driver.Value) (equal bool) {
+	if v1 == nil {
+		v1 = f.Null()
+	}
+	if v2 == nil {
+		v2 = f.Null()
+	}
+	switch t1 := v1.(type) {
+	case undefinedValue:
+		_, ok := v2.(undefinedValue)
+		return ok
+	case nullValue:
+		_, ok := v2.(nullValue)
+		return ok
+	case booleanValue:
+		t2, ok := v2.(booleanValue)
+		return ok && t1.v == t2.v
+	case numberValue:
+		t2, ok := v2.(numberValue)
+		return ok && t1.v == t2.v
+	case stringValue:
+		t2, ok := v2.(stringValue)
+		return ok && t1.v == t2.v
+	case objectValue:
+		t2, ok := v2.(objectValue)
+		return ok && t1.h.id == t2.h.id
+	case functionValue:
+		t2, ok := v2.(functionValue)
+		return ok && t1.h.id == t2.h.id
+	default:
+		panic(fmt.Errorf("value was not our type: %T", v1))
+	}
+}
+
+func (f *Factory) Global() driver.Object {
+	return f.global
+}
+
+func (f *Factory) Undefined() driver.Value {
+	return undefinedValue{}
+}
+
+func (f *Factory) Null() driver.Value {
+	return nullValue{}
+}
+
+func (f *Factory) Boolean(t bool) driver.Value {
+	return booleanValue{v: t}
+}
+
+func (f *Factory) Number(n float64) driver.Value {
+	return numberValue{v: n}
+}
+
+func (f *Factory) String(s string) driver.Value {
+	return stringValue{v: s}
+}
+
+// Function creates a JavaScript function that calls fn on the Go side.
+// JavaScript does not wait for fn to run, so its return value is ignored and the function returns undefined.
+func (f *Factory) Function(fn func(this driver.Object, args ...driver.Value) driver.Value) driver.Function {
+	f.lock.Lock()
+	f.lastCallback++
+	callback := f.lastCallback
+	f.callbacks[callback] = fn
+	f.lock.Unlock()
+	rv := f.roundTrip(message{Op: opFunction, Index: callback})
+	function := f.decode(rv.Value).(functionValue)
+	function.callback = callback
+	return function
+}
+
+func (f *Factory) Buffer(size int) driver.Buffer {
+	rv := f.roundTrip(message{Op: opBuffer, Index: size})
+	return buffer{
+		factory: f,
+		array:   f.decode(rv.Value).(objectValue),
+		size:    size,
+	}
+}
+
+func (f *Factory) Array(values ...driver.Value) driver.Object {
+	rv := f.roundTrip(message{Op: opArray, Args: f.encodeAll(values)}, values)
+	return f.decode(rv.Value).(objectValue)
+}
+
+func (f *Factory) LiveCallbacks() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.callbacks)
+}
+
+var _ driver.Factory = &Factory{}
+
+// roundTrip sends a request and waits for its return.
+// The values are kept alive until then, so that their ids are not freed while the request is underway.
+// A thrown exception panics with an exception, and a lost connection panics with an error.
+func (f *Factory) roundTrip(request message, keepAlive ...interface{}) message {
+	defer runtime.KeepAlive(keepAlive)
+	returned := make(chan message, 1)
+	f.lock.Lock()
+	if f.err != nil {
+		err := f.err
+		f.lock.Unlock()
+		panic(fmt.Errorf("remote connection lost: %w", err))
+	}
+	f.lastID++
+	request.ID = f.lastID
+	request.Free, f.free = f.free, nil
+	f.pending[request.ID] = returned
+	f.lock.Unlock()
+
+	if err := f.write(request); err != nil {
+		f.fail(fmt.Errorf("writing request: %w", err))
+	}
+	select {
+	case rv := <-returned:
+		if rv.Error != nil {
+			panic(exception{value: f.decode(rv.Error)})
+		}
+		return rv
+	case <-f.done:
+		panic(fmt.Errorf("remote connection lost: %w", f.Err()))
+	}
+}
+
+func (f *Factory) write(m message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+	return f.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (f *Factory) readLoop() {
+	for {
+		_, data, err := f.conn.ReadMessage()
+		if err != nil {
+			f.fail(fmt.Errorf("reading message: %w", err))
+			return
+		}
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			f.fail(fmt.Errorf("decoding message: %w", err))
+			return
+		}
+		switch m.Op {
+		case opReturn:
+			f.lock.Lock()
+			returned, ok := f.pending[m.ID]
+			delete(f.pending, m.ID)
+			f.lock.Unlock()
+			if !ok {
+				f.fail(fmt.Errorf("return for unknown request %d", m.ID))
+				return
+			}
+			returned <- m
+		case opCallback:
+			f.queue.push(m)
+		default:
+			f.fail(fmt.Errorf("unknown message op: %q", m.Op))
+			return
+		}
+	}
+}
+
+// dispatchLoop calls the callbacks one at a time, in the order JavaScript called them.
+// It runs separately from readLoop, so that callbacks can make requests of their own.
+func (f *Factory) dispatchLoop() {
+	for {
+		m, ok := f.queue.pop(f.done)
+		if !ok {
+			return
+		}
+		f.dispatch(m)
+	}
+}
+
+func (f *Factory) dispatch(m message) {
+	defer func() {
+		// Once the connection is lost, requests made by a callback panic; there is nobody left to tell.
+		if p := recover(); p != nil && f.Err() == nil {
+			panic(p)
+		}
+	}()
+	this, _ := f.decode(m.Target).ToObject()
+	args := f.decodeAll(m.Args)
+	f.lock.Lock()
+	fn, ok := f.callbacks[m.Index]
+	f.lock.Unlock()
+	if !ok {
+		return
+	}
+	fn(this, args...)
+}
+
+// handle is a reference to an entry in the shim's table.
+// When it is garbage collected, the entry is freed.
+type handle struct {
+	factory *Factory
+	id      int
+}
+
+func (f *Factory) newHandle(id int) *handle {
+	h := &handle{
+		factory: f,
+		id:      id,
+	}
+	if id != 0 {
+		runtime.SetFinalizer(h, (*handle).finalize)
+	}
+	return h
+}
+
+func (h *handle) finalize() {
+	h.factory.lock.Lock()
+	defer h.factory.lock.Unlock()
+	h.factory.free = append(h.factory.free, h.id)
+}
+
+func (f *Factory) encode(v driver.Value) wireValue {
+	if v == nil {
+		return wireValue{Type: typeNull}
+	}
+	switch t := v.(type) {
+	case undefinedValue:
+		return wireValue{Type: typeUndefined}
+	case nullValue:
+		return wireValue{Type: typeNull}
+	case booleanValue:
+		return wireValue{Type: typeBoolean, Value: fmt.Sprintf("%t", t.v)}
+	case numberValue:
+		return wireValue{Type: typeNumber, Value: formatNumber(t.v)}
+	case stringValue:
+		return wireValue{Type: typeString, Value: t.v}
+	case objectValue:
+		return wireValue{Type: typeObject, ID: t.h.id}
+	case functionValue:
+		return wireValue{Type: typeFunction, ID: t.h.id}
+	default:
+		panic(fmt.Errorf("value was not our type: %T", v))
+	}
+}
+
+func (f *Factory) encodeAll(values []driver.Value) []wireValue {
+	encoded := make([]wireValue, len(values))
+	for i, v := range values {
+		encoded[i] = f.encode(v)
+	}
+	return encoded
+}
+
+func (f *Factory) decode(w *wireValue) driver.Value {
+	if w == nil {
+		return undefinedValue{}
+	}
+	switch w.Type {
+	case typeUndefined:
+		return undefinedValue{}
+	case typeNull:
+		return nullValue{}
+	case typeBoolean:
+		return booleanValue{v: w.Value == "true"}
+	case typeNumber:
+		n, err := parseNumber(w.Value)
+		if err != nil {
+			panic(err)
+		}
+		return numberValue{v: n}
+	case typeString:
+		return stringValue{v: w.Value}
+	case typeObject:
+		return objectValue{h: f.newHandle(w.ID)}
+	case typeFunction:
+		return functionValue{h: f.newHandle(w.ID)}
+	default:
+		panic(fmt.Errorf("unknown value type: %q", w.Type))
+	}
+}
+
+func (f *Factory) decodeAll(values []wireValue) []driver.Value {
+	decoded := make([]driver.Value, len(values))
+	for i := range values {
+		decoded[i] = f.decode(&values[i])
+	}
+	return decoded
+}
+
+// callbackQueue is an unbounded queue of callback messages,
+// so that readLoop never waits for a callback to finish.
+type callbackQueue struct {
+	lock     sync.Mutex
+	messages []message
+	wake     chan struct{}
+}
+
+func newCallbackQueue() *callbackQueue {
+	return &callbackQueue{
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (q *callbackQueue) push(m message) {
+	q.lock.Lock()
+	q.messages = append(q.messages, m)
+	q.lock.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *callbackQueue) pop(done <-chan struct{}) (message, bool) {
+	for {
+		q.lock.Lock()
+		if len(q.messages) > 0 {
+			m := q.messages[0]
+			q.messages = q.messages[1:]
+			q.lock.Unlock()
+			return m, true
+		}
+		q.lock.Unlock()
+		select {
+		case <-q.wake:
+		case <-done:
+			return message{}, false
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+/*
+The protocol is a stream of JSON encoded messages over a WebSocket.
+
+The Go side sends requests, each of which is answered by a "return" message with the same id.
+The JavaScript side executes requests one at a time, in the order they were sent.
+When JavaScript calls a function created by Factory.Function, it sends a "callback" message.
+Callbacks can not return a value to JavaScript, because JavaScript can not wait for the Go side to answer.
+
+Objects and functions are sent as ids, referring to a table on the JavaScript side.
+The same object always has the same id, and id 0 is the global object.
+Every time the JavaScript side sends an id, it increments the reference count of its table entry.
+The Go side decrements it, by listing the id in the free field of a later request,
+once the value it decoded is garbage collected.
+
+	request   {"id": 1, "op": "get", "target": {"type": "object", "id": 0}, "key": "document"}
+	return    {"id": 1, "op": "return", "value": {"type": "object", "id": 4}}
+	callback  {"op": "callback", "index": 2, "target": {"type": "undefined"}, "args": [...]}
+*/
+
+const (
+	opReturn     = "return"
+	opCallback   = "callback"
+	opGet        = "get"
+	opSet        = "set"
+	opHas        = "has"
+	opDelete     = "delete"
+	opKeys       = "keys"
+	opIndex      = "index"
+	opSetIndex   = "setIndex"
+	opInstanceOf = "instanceOf"
+	opCall       = "call"
+	opNew        = "new"
+	opArray      = "array"
+	opFunction   = "function"
+	opRelease    = "release"
+	opBuffer     = "buffer"
+	opPut        = "put"
+	opRead       = "read"
+	opView       = "view"
+)
+
+// message is used for requests, returns and callbacks; only the fields relevant to the op are set.
+type message struct {
+	ID     int         `json:"id,omitempty"`
+	Op     string      `json:"op"`
+	Target *wireValue  `json:"target,omitempty"`
+	Key    string      `json:"key,omitempty"`
+	Index  int         `json:"index,omitempty"`
+	Args   []wireValue `json:"args,omitempty"`
+	Data   []byte      `json:"data,omitempty"`
+	Free   []int       `json:"free,omitempty"`
+	Value  *wireValue  `json:"value,omitempty"`
+	Keys   []string    `json:"keys,omitempty"`
+	Error  *wireValue  `json:"error,omitempty"`
+}
+
+const (
+	typeUndefined = "undefined"
+	typeNull      = "null"
+	typeBoolean   = "boolean"
+	typeNumber    = "number"
+	typeString    = "string"
+	typeObject    = "object"
+	typeFunction  = "function"
+)
+
+// wireValue is the encoding of a single JavaScript value.
+// Booleans and numbers are encoded as strings, so that NaN and the infinities survive JSON.
+type wireValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+	ID    int    `json:"id,omitempty"`
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func parseNumber(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing number %q: %w", s, err)
+	}
+	return f, nil
+}
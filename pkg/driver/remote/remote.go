@@ -0,0 +1,37 @@
+// Package remote implements a driver for native Go processes.
+// Every operation is sent over a WebSocket to a small JavaScript shim running in a browser page,
+// so that code written against the driver can be run, debugged and race tested natively
+// while it renders in a browser tab.
+//
+// pkg/bootstrap serves the shim; Serve is a Go stand-in for it.
+// The tests only run against Serve; the shim itself has not been tested against a real page.
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// Handler accepts WebSocket connections from the shim.
+// For every connection, run is called with a Factory for the connected page,
+// and the connection is closed when it returns.
+func Handler(run func(factory *Factory)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		conn, err := upgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "upgrading remote driver connection: %v\n", err)
+			return
+		}
+		// Servers with a WriteTimeout leave a deadline on the connection, which would end the session.
+		_ = conn.UnderlyingConn().SetDeadline(time.Time{})
+		factory := Connect(conn)
+		defer func() { _ = factory.Close() }()
+		run(factory)
+	})
+}
@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/drivertest"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+	"github.com/gorilla/websocket"
+)
+
+// connect serves a fakejs factory over a WebSocket, and returns the remote Factory talking to it.
+// The returned function disconnects them.
+func connect(t *testing.T) (factory *Factory, js *fakejs.Factory, disconnect func()) {
+	t.Helper()
+	js = fakejs.Open()
+	factories := make(chan *Factory)
+	done := make(chan struct{})
+	server := httptest.NewServer(Handler(func(factory *Factory) {
+		factories <- factory
+		<-done
+	}))
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dialing: %v", err)
+	}
+	go func() { _ = Serve(conn, js) }()
+	disconnect = func() {
+		close(done)
+		_ = conn.Close()
+		server.Close()
+	}
+	return <-factories, js, disconnect
+}
+
+func TestRemoteValues(t *testing.T) {
+	factory, js, disconnect := connect(t)
+	defer disconnect()
+	calc := js.Object()
+	calc.SetFunc("add", func(this driver.Object, args ...driver.Value) driver.Value {
+		a, _ := args[0].ToFloat64()
+		b, _ := args[1].ToFloat64()
+		return js.Number(a + b)
+	})
+	calc.SetFunc("fail", func(this driver.Object, args ...driver.Value) driver.Value {
+		js.Throw("TypeError", "no good")
+		return nil
+	})
+	calc.Set("name", js.String("calc"))
+	js.Global().Set("calc", calc)
+
+	remoteCalc, ok := factory.Global().Get("calc").ToObject()
+	if !ok {
+		t.Fatalf("calc is not an object")
+	}
+	if !factory.Equal(remoteCalc, factory.Global().Get("calc")) {
+		t.Fatalf("expected the same object to be equal")
+	}
+	if rv := driver.Bind(remoteCalc, "add")(factory.Number(1), factory.Number(2)); !factory.Equal(rv, factory.Number(3)) {
+		t.Fatalf("expected 3, got: %v", rv)
+	}
+	calls := js.CallsTo("add")
+	if len(calls) != 1 || !js.Equal(calls[0].This, calc) {
+		t.Fatalf("expected add to be called once on calc, got: %v", calls)
+	}
+	if want, got := []string{"add", "fail", "name"}, remoteCalc.Keys(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected keys %v, got: %v", want, got)
+	}
+	remoteCalc.Set("nan", factory.Number(math.NaN()))
+	if f, _ := remoteCalc.Get("nan").ToFloat64(); !math.IsNaN(f) {
+		t.Fatalf("expected NaN, got: %v", f)
+	}
+	remoteCalc.Delete("nan")
+	if remoteCalc.Has("nan") || !remoteCalc.Has("name") {
+		t.Fatalf("expected nan to be deleted and name to be present")
+	}
+
+	fail, _ := remoteCalc.Get("fail").ToFunction()
+	_, err := fail.CallErr(remoteCalc)
+	var jsErr *driver.Error
+	if !errors.As(err, &jsErr) || jsErr.Name != "TypeError" || jsErr.Message != "no good" {
+		t.Fatalf("expected TypeError, got: %v", err)
+	}
+
+	array := factory.Array(factory.String("a"), factory.Null())
+	if got := array.Index(0); !factory.Equal(got, factory.String("a")) {
+		t.Fatalf("expected a, got: %v", got)
+	}
+	arrayConstructor, _ := factory.Global().Get("Array").ToFunction()
+	if !array.InstanceOf(arrayConstructor) {
+		t.Fatalf("expected an array to be an instance of Array")
+	}
+}
+
+func TestRemoteCallback(t *testing.T) {
+	factory, js, disconnect := connect(t)
+	defer disconnect()
+	js.Global().Set("invoke", js.Func("invoke", func(this driver.Object, args ...driver.Value) driver.Value {
+		fn, _ := args[0].ToFunction()
+		return fn.Call(nil, args[1:]...)
+	}))
+	called := make(chan driver.Value, 1)
+	callback := factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
+		called <- args[0]
+		return nil
+	})
+	driver.Bind(factory.Global(), "invoke")(callback, factory.String("hello"))
+	select {
+	case arg := <-called:
+		if !factory.Equal(arg, factory.String("hello")) {
+			t.Fatalf("expected hello, got: %v", arg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("callback was not called")
+	}
+	if factory.LiveCallbacks() != 1 || js.LiveCallbacks() != 1 {
+		t.Fatalf("expected 1 live callback on both sides, got: %d and %d", factory.LiveCallbacks(), js.LiveCallbacks())
+	}
+	callback.Release()
+	if factory.LiveCallbacks() != 0 || js.LiveCallbacks() != 0 {
+		t.Fatalf("expected no live callbacks on both sides, got: %d and %d", factory.LiveCallbacks(), js.LiveCallbacks())
+	}
+}
+
+func TestRemoteAwait(t *testing.T) {
+	factory, js, disconnect := connect(t)
+	defer disconnect()
+	promise, resolve, _ := js.Promise()
+	js.Global().Set("promise", promise)
+	go resolve(js.Number(5))
+	remotePromise, _ := factory.Global().Get("promise").ToObject()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	value, err := driver.Await(ctx, factory, remotePromise)
+	if err != nil {
+		t.Fatalf("awaiting: %v", err)
+	}
+	if !factory.Equal(value, factory.Number(5)) {
+		t.Fatalf("expected 5, got: %v", value)
+	}
+}
+
+func TestRemoteBuffer(t *testing.T) {
+	factory, _, disconnect := connect(t)
+	defer disconnect()
+	buffer := factory.Buffer(8)
+	if n := buffer.Put([]byte{1, 0, 2, 0, 0, 0, 128, 63}); n != 8 {
+		t.Fatalf("expected to put 8 bytes, put: %d", n)
+	}
+	if got, _ := buffer.AsFloat32Array().Index(1).ToFloat64(); got != 1 {
+		t.Fatalf("expected second float32 to be 1, got: %v", got)
+	}
+	slice := buffer.Slice(2, 4)
+	if got, _ := slice.AsUint16Array().Index(0).ToFloat64(); got != 2 {
+		t.Fatalf("expected first uint16 of the slice to be 2, got: %v", got)
+	}
+	slice.Put([]byte{9})
+	data := make([]byte, 4)
+	if n := buffer.Get(data); n != 4 || !reflect.DeepEqual(data, []byte{1, 0, 9, 0}) {
+		t.Fatalf("expected to get [1 0 9 0], got %d bytes: %v", n, data)
+	}
+}
+
+func TestRemoteConnectionLost(t *testing.T) {
+	factory, _, disconnect := connect(t)
+	defer disconnect()
+	_ = factory.Close()
+	select {
+	case <-factory.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the factory to be done")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	factory.Global().Get("window")
+}
+
+func TestConformance(t *testing.T) {
+	factory, _, disconnect := connect(t)
+	defer disconnect()
+	drivertest.Run(t, factory, drivertest.Config{AsyncCallbacks: true})
+}
@@ -0,0 +1,336 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/gorilla/websocket"
+)
+
+// Serve answers the requests of the Factory at the other end of conn, by executing them against factory.
+// It is a Go stand-in for the JavaScript shim, which makes it possible to test the protocol
+// and the code using it without a browser, for example with a fakejs factory.
+// Serve returns when the connection is closed.
+func Serve(conn *websocket.Conn, factory driver.Factory) error {
+	s := &server{
+		conn:      conn,
+		factory:   factory,
+		values:    make(map[int]*serverEntry),
+		ids:       make(map[interface{}]int),
+		callbacks: make(map[int]driver.Function),
+	}
+	s.values[0] = &serverEntry{value: factory.Global(), refs: -1}
+	s.identify(factory.Global(), 0)
+	defer func() {
+		for _, callback := range s.callbacks {
+			callback.Release()
+		}
+	}()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return fmt.Errorf("reading message: %w", err)
+		}
+		var request message
+		if err := json.Unmarshal(data, &request); err != nil {
+			return fmt.Errorf("decoding message: %w", err)
+		}
+		for _, id := range request.Free {
+			s.free(id)
+		}
+		if err := s.write(s.execute(request)); err != nil {
+			return fmt.Errorf("writing return: %w", err)
+		}
+	}
+}
+
+type server struct {
+	conn      *websocket.Conn
+	factory   driver.Factory
+	writeLock sync.Mutex
+	// callbacks is only used by the goroutine running Serve.
+	callbacks map[int]driver.Function
+
+	lock   sync.Mutex
+	lastID int
+	values map[int]*serverEntry
+	// ids finds the id of values that can be compared with ==, so that the same object keeps the same id.
+	ids map[interface{}]int
+}
+
+type serverEntry struct {
+	value driver.Value
+	// refs is the number of times the id was sent without being freed; it is negative for the global object.
+	refs int
+}
+
+func (s *server) write(m message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (s *server) execute(request message) (rv message) {
+	rv = message{ID: request.ID, Op: opReturn}
+	defer func() {
+		if p := recover(); p != nil {
+			thrown := s.thrown(p)
+			rv.Error = &thrown
+		}
+	}()
+	var value driver.Value
+	switch request.Op {
+	case opGet:
+		value = s.object(request.Target).Get(request.Key)
+	case opSet:
+		s.object(request.Target).Set(request.Key, s.arg(request, 0))
+	case opHas:
+		value = s.factory.Boolean(s.object(request.Target).Has(request.Key))
+	case opDelete:
+		s.object(request.Target).Delete(request.Key)
+	case opKeys:
+		rv.Keys = s.object(request.Target).Keys()
+	case opIndex:
+		value = s.object(request.Target).Index(request.Index)
+	case opSetIndex:
+		s.object(request.Target).SetIndex(request.Index, s.arg(request, 0))
+	case opInstanceOf:
+		constructor, ok := s.arg(request, 0).ToFunction()
+		if !ok {
+			panic(fmt.Errorf("right-hand side of instanceof is not callable"))
+		}
+		value = s.factory.Boolean(s.object(request.Target).InstanceOf(constructor))
+	case opCall:
+		this, _ := s.arg(request, 0).ToObject()
+		var args []driver.Value
+		if len(request.Args) > 1 {
+			args = s.decodeAll(request.Args[1:])
+		}
+		var err error
+		value, err = s.function(request.Target).CallErr(this, args...)
+		if err != nil {
+			panic(err)
+		}
+	case opNew:
+		obj, err := s.function(request.Target).NewErr(s.decodeAll(request.Args)...)
+		if err != nil {
+			panic(err)
+		}
+		value = obj
+	case opArray:
+		value = s.factory.Array(s.decodeAll(request.Args)...)
+	case opFunction:
+		value = s.callback(request.Index)
+	case opRelease:
+		if callback, ok := s.callbacks[request.Index]; ok {
+			callback.Release()
+			delete(s.callbacks, request.Index)
+		}
+	case opBuffer:
+		value = s.factory.Buffer(request.Index).AsUint8Array()
+	case opPut:
+		array := s.object(request.Target)
+		for i, b := range request.Data {
+			array.SetIndex(i, s.factory.Number(float64(b)))
+		}
+	case opRead:
+		array := s.object(request.Target)
+		rv.Data = make([]byte, request.Index)
+		for i := range rv.Data {
+			b, _ := array.Index(i).ToFloat64()
+			rv.Data[i] = byte(b)
+		}
+	case opView:
+		array := s.object(request.Target)
+		constructor, ok := s.factory.Global().Get(request.Key).ToFunction()
+		if !ok {
+			panic(fmt.Errorf("unknown typed array: %s", request.Key))
+		}
+		byteOffset, _ := array.Get("byteOffset").ToFloat64()
+		offset, _ := s.arg(request, 0).ToFloat64()
+		value = constructor.New(array.Get("buffer"), s.factory.Number(byteOffset+offset), s.arg(request, 1))
+	default:
+		panic(fmt.Errorf("unknown op: %q", request.Op))
+	}
+	if value != nil {
+		encoded := s.encode(value)
+		rv.Value = &encoded
+	}
+	return rv
+}
+
+// callback creates a function that sends a callback message for the given callback index.
+func (s *server) callback(index int) driver.Function {
+	function := s.factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
+		var encodedThis wireValue
+		if this == nil {
+			encodedThis = wireValue{Type: typeUndefined}
+		} else {
+			encodedThis = s.encode(this)
+		}
+		// An error here means the connection is lost, which Serve will find out on its next read.
+		_ = s.write(message{
+			Op:     opCallback,
+			Index:  index,
+			Target: &encodedThis,
+			Args:   s.encodeAll(args),
+		})
+		return s.factory.Undefined()
+	})
+	s.callbacks[index] = function
+	return function
+}
+
+// thrown converts a panic during execution into the value JavaScript would have thrown.
+func (s *server) thrown(p interface{}) wireValue {
+	var jsErr *driver.Error
+	if err, ok := p.(error); ok && errors.As(err, &jsErr) {
+		return s.encode(jsErr.Value)
+	}
+	return s.encode(s.factory.String(fmt.Sprint(p)))
+}
+
+func (s *server) arg(request message, i int) driver.Value {
+	if i >= len(request.Args) {
+		return s.factory.Undefined()
+	}
+	return s.decode(&request.Args[i])
+}
+
+func (s *server) object(w *wireValue) driver.Object {
+	obj, ok := s.decode(w).ToObject()
+	if !ok {
+		panic(fmt.Errorf("target is not an object"))
+	}
+	return obj
+}
+
+func (s *server) function(w *wireValue) driver.Function {
+	function, ok := s.decode(w).ToFunction()
+	if !ok {
+		panic(fmt.Errorf("target is not a function"))
+	}
+	return function
+}
+
+func (s *server) encode(v driver.Value) wireValue {
+	if v == nil || v.IsNull() {
+		return wireValue{Type: typeNull}
+	}
+	if v.IsUndefined() {
+		return wireValue{Type: typeUndefined}
+	}
+	if b, ok := v.ToBoolean(); ok {
+		return wireValue{Type: typeBoolean, Value: fmt.Sprintf("%t", b)}
+	}
+	if n, ok := v.ToFloat64(); ok {
+		return wireValue{Type: typeNumber, Value: formatNumber(n)}
+	}
+	if str, ok := v.ToString(); ok {
+		return wireValue{Type: typeString, Value: str}
+	}
+	if _, ok := v.ToFunction(); ok {
+		return wireValue{Type: typeFunction, ID: s.ref(v)}
+	}
+	return wireValue{Type: typeObject, ID: s.ref(v)}
+}
+
+func (s *server) encodeAll(values []driver.Value) []wireValue {
+	encoded := make([]wireValue, len(values))
+	for i, v := range values {
+		encoded[i] = s.encode(v)
+	}
+	return encoded
+}
+
+func (s *server) decode(w *wireValue) driver.Value {
+	if w == nil {
+		return s.factory.Undefined()
+	}
+	switch w.Type {
+	case typeUndefined:
+		return s.factory.Undefined()
+	case typeNull:
+		return s.factory.Null()
+	case typeBoolean:
+		return s.factory.Boolean(w.Value == "true")
+	case typeNumber:
+		n, err := parseNumber(w.Value)
+		if err != nil {
+			panic(err)
+		}
+		return s.factory.Number(n)
+	case typeString:
+		return s.factory.String(w.Value)
+	case typeObject, typeFunction:
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		entry, ok := s.values[w.ID]
+		if !ok {
+			panic(fmt.Errorf("unknown id: %d", w.ID))
+		}
+		return entry.value
+	default:
+		panic(fmt.Errorf("unknown value type: %q", w.Type))
+	}
+}
+
+func (s *server) decodeAll(values []wireValue) []driver.Value {
+	decoded := make([]driver.Value, len(values))
+	for i := range values {
+		decoded[i] = s.decode(&values[i])
+	}
+	return decoded
+}
+
+// ref returns the id of a value, adding a reference to it.
+func (s *server) ref(v driver.Value) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if reflect.TypeOf(v).Comparable() {
+		if id, ok := s.ids[v]; ok {
+			if entry := s.values[id]; entry.refs >= 0 {
+				entry.refs++
+			}
+			return id
+		}
+	}
+	s.lastID++
+	s.values[s.lastID] = &serverEntry{value: v, refs: 1}
+	s.identify(v, s.lastID)
+	return s.lastID
+}
+
+func (s *server) identify(v driver.Value, id int) {
+	if reflect.TypeOf(v).Comparable() {
+		s.ids[v] = id
+	}
+}
+
+func (s *server) free(id int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, ok := s.values[id]
+	if !ok || entry.refs < 0 {
+		return
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	delete(s.values, id)
+	if reflect.TypeOf(entry.value).Comparable() {
+		delete(s.ids, entry.value)
+	}
+}
@@ -0,0 +1,144 @@
+package remote
+
+// Shim is the JavaScript side of the protocol.
+// It connects to the WebSocket at the path in its data-socket attribute, relative to the page.
+const Shim = `(function () {
+    "use strict";
+    const script = document.currentScript;
+    const url = new URL(script.dataset.socket || "_remote", location.href);
+    url.protocol = location.protocol === "https:" ? "wss:" : "ws:";
+    const socket = new WebSocket(url.href);
+
+    // values maps an id to a value and its reference count; ids is the reverse.
+    const values = new Map([[0, {value: globalThis, refs: Infinity}]]);
+    const ids = new Map([[globalThis, 0]]);
+    const callbacks = new Map();
+    let lastId = 0;
+
+    function ref(value) {
+        let id = ids.get(value);
+        if (id === undefined) {
+            id = ++lastId;
+            ids.set(value, id);
+            values.set(id, {value: value, refs: 0});
+        }
+        values.get(id).refs++;
+        return id;
+    }
+
+    function free(id) {
+        const entry = values.get(id);
+        if (entry === undefined || --entry.refs > 0) {
+            return;
+        }
+        values.delete(id);
+        ids.delete(entry.value);
+    }
+
+    function encode(value) {
+        switch (typeof value) {
+            case "undefined":
+                return {type: "undefined"};
+            case "boolean":
+                return {type: "boolean", value: String(value)};
+            case "number":
+                return {type: "number", value: String(value)};
+            case "string":
+                return {type: "string", value: value};
+            case "function":
+                return {type: "function", id: ref(value)};
+            default:
+                if (value === null) {
+                    return {type: "null"};
+                }
+                return {type: "object", id: ref(value)};
+        }
+    }
+
+    function decode(wire) {
+        if (wire === undefined) {
+            return undefined;
+        }
+        switch (wire.type) {
+            case "undefined":
+                return undefined;
+            case "null":
+                return null;
+            case "boolean":
+                return wire.value === "true";
+            case "number":
+                return Number(wire.value);
+            case "string":
+                return wire.value || "";
+            default:
+                const entry = values.get(wire.id || 0);
+                if (entry === undefined) {
+                    throw new Error("unknown id: " + wire.id);
+                }
+                return entry.value;
+        }
+    }
+
+    function toBase64(bytes) {
+        let binary = "";
+        for (let i = 0; i < bytes.length; i += 0x8000) {
+            binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+        }
+        return btoa(binary);
+    }
+
+    function fromBase64(data) {
+        const binary = atob(data || "");
+        const bytes = new Uint8Array(binary.length);
+        for (let i = 0; i < binary.length; i++) {
+            bytes[i] = binary.charCodeAt(i);
+        }
+        return bytes;
+    }
+
+    const ops = {
+        get: (target, m) => ({value: encode(target[m.key])}),
+        set: (target, m, args) => { target[m.key] = args[0]; },
+        has: (target, m) => ({value: encode(m.key in target)}),
+        delete: (target, m) => { delete target[m.key]; },
+        keys: (target) => ({keys: Object.keys(target)}),
+        index: (target, m) => ({value: encode(target[m.index || 0])}),
+        setIndex: (target, m, args) => { target[m.index || 0] = args[0]; },
+        instanceOf: (target, m, args) => ({value: encode(target instanceof args[0])}),
+        call: (target, m, args) => ({value: encode(target.apply(args[0], args.slice(1)))}),
+        new: (target, m, args) => ({value: encode(new target(...args))}),
+        array: (target, m, args) => ({value: encode(Array.of(...args))}),
+        function: (target, m) => {
+            const index = m.index;
+            callbacks.set(index, true);
+            return {value: encode(function (...args) {
+                if (!callbacks.has(index)) {
+                    throw new Error("call to released function");
+                }
+                socket.send(JSON.stringify({op: "callback", index: index, target: encode(this), args: args.map(encode)}));
+            })};
+        },
+        release: (target, m) => { callbacks.delete(m.index); },
+        buffer: (target, m) => ({value: encode(new Uint8Array(m.index || 0))}),
+        put: (target, m) => { target.set(fromBase64(m.data)); },
+        read: (target, m) => ({data: toBase64(target.subarray(0, m.index || 0))}),
+        view: (target, m, args) => ({value: encode(new globalThis[m.key](target.buffer, target.byteOffset + args[0], args[1]))}),
+    };
+
+    socket.onmessage = (event) => {
+        const m = JSON.parse(event.data);
+        (m.free || []).forEach(free);
+        const reply = {id: m.id, op: "return"};
+        try {
+            const args = (m.args || []).map(decode);
+            Object.assign(reply, ops[m.op](decode(m.target), m, args));
+        } catch (err) {
+            reply.error = encode(err);
+        }
+        socket.send(JSON.stringify(reply));
+    };
+    socket.onclose = () => {
+        console.log("remote driver disconnected");
+    };
+})();
+`
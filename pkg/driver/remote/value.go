@@ -0,0 +1,272 @@
+package remote
+
+import (
+	"fmt"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+type undefinedValue struct {
+	empty
+}
+
+func (v undefinedValue) IsUndefined() bool {
+	return true
+}
+
+func (v undefinedValue) TypeOf() string {
+	return "undefined"
+}
+
+var _ driver.Value = undefinedValue{}
+
+type nullValue struct {
+	empty
+}
+
+func (v nullValue) IsNull() bool {
+	return true
+}
+
+func (v nullValue) TypeOf() string {
+	return "object"
+}
+
+var _ driver.Value = nullValue{}
+
+type booleanValue struct {
+	empty
+	v bool
+}
+
+func (v booleanValue) TypeOf() string {
+	return "boolean"
+}
+
+func (v booleanValue) ToBoolean() (bool, bool) {
+	return v.v, true
+}
+
+var _ driver.Value = booleanValue{}
+
+type numberValue struct {
+	empty
+	v float64
+}
+
+func (v numberValue) TypeOf() string {
+	return "number"
+}
+
+func (v numberValue) ToFloat64() (float64, bool) {
+	return v.v, true
+}
+
+var _ driver.Value = numberValue{}
+
+type stringValue struct {
+	empty
+	v string
+}
+
+func (v stringValue) TypeOf() string {
+	return "string"
+}
+
+func (v stringValue) ToString() (string, bool) {
+	return v.v, true
+}
+
+var _ driver.Value = stringValue{}
+
+type objectValue struct {
+	empty
+	h *handle
+}
+
+func (o objectValue) TypeOf() string {
+	return "object"
+}
+
+func (o objectValue) ToObject() (driver.Object, bool) {
+	return o, true
+}
+
+func (o objectValue) Get(key string) driver.Value {
+	f := o.h.factory
+	rv := f.roundTrip(message{Op: opGet, Target: o.wire(), Key: key}, o)
+	return f.decode(rv.Value)
+}
+
+func (o objectValue) Set(key string, value driver.Value) {
+	f := o.h.factory
+	f.roundTrip(message{Op: opSet, Target: o.wire(), Key: key, Args: f.encodeAll([]driver.Value{value})}, o, value)
+}
+
+func (o objectValue) Has(key string) bool {
+	f := o.h.factory
+	rv := f.roundTrip(message{Op: opHas, Target: o.wire(), Key: key}, o)
+	has, _ := f.decode(rv.Value).ToBoolean()
+	return has
+}
+
+func (o objectValue) Delete(key string) {
+	o.h.factory.roundTrip(message{Op: opDelete, Target: o.wire(), Key: key}, o)
+}
+
+func (o objectValue) Keys() []string {
+	rv := o.h.factory.roundTrip(message{Op: opKeys, Target: o.wire()}, o)
+	return rv.Keys
+}
+
+func (o objectValue) Index(i int) driver.Value {
+	f := o.h.factory
+	rv := f.roundTrip(message{Op: opIndex, Target: o.wire(), Index: i}, o)
+	return f.decode(rv.Value)
+}
+
+func (o objectValue) SetIndex(i int, value driver.Value) {
+	f := o.h.factory
+	f.roundTrip(message{Op: opSetIndex, Target: o.wire(), Index: i, Args: f.encodeAll([]driver.Value{value})}, o, value)
+}
+
+func (o objectValue) InstanceOf(constructor driver.Function) bool {
+	f := o.h.factory
+	rv := f.roundTrip(message{Op: opInstanceOf, Target: o.wire(), Args: f.encodeAll([]driver.Value{constructor})}, o, constructor)
+	is, _ := f.decode(rv.Value).ToBoolean()
+	return is
+}
+
+func (o objectValue) wire() *wireValue {
+	return &wireValue{Type: typeObject, ID: o.h.id}
+}
+
+func (o objectValue) String() string {
+	return fmt.Sprintf("[object #%d]", o.h.id)
+}
+
+var _ driver.Object = objectValue{}
+
+type functionValue struct {
+	empty
+	h *handle
+	// callback is only set for functions created by Factory.Function.
+	callback int
+}
+
+func (fn functionValue) TypeOf() string {
+	return "function"
+}
+
+func (fn functionValue) ToFunction() (driver.Function, bool) {
+	return fn, true
+}
+
+func (fn functionValue) New(args ...driver.Value) driver.Object {
+	f := fn.h.factory
+	rv := f.roundTrip(message{Op: opNew, Target: fn.wire(), Args: f.encodeAll(args)}, fn, args)
+	obj, ok := f.decode(rv.Value).ToObject()
+	if !ok {
+		panic(fmt.Errorf("constructor did not return an object"))
+	}
+	return obj
+}
+
+func (fn functionValue) Call(this driver.Object, args ...driver.Value) driver.Value {
+	f := fn.h.factory
+	// A nil this is passed as undefined, like wasmjs does.
+	var thisValue driver.Value = f.Undefined()
+	if this != nil {
+		thisValue = this
+	}
+	thisAndArgs := append([]driver.Value{thisValue}, args...)
+	rv := f.roundTrip(message{Op: opCall, Target: fn.wire(), Args: f.encodeAll(thisAndArgs)}, fn, thisAndArgs)
+	return f.decode(rv.Value)
+}
+
+func (fn functionValue) NewErr(args ...driver.Value) (obj driver.Object, err error) {
+	defer recoverException(&err)
+	return fn.New(args...), nil
+}
+
+func (fn functionValue) CallErr(this driver.Object, args ...driver.Value) (rv driver.Value, err error) {
+	defer recoverException(&err)
+	return fn.Call(this, args...), nil
+}
+
+func (fn functionValue) Release() {
+	if fn.callback == 0 {
+		return
+	}
+	f := fn.h.factory
+	f.lock.Lock()
+	_, ok := f.callbacks[fn.callback]
+	delete(f.callbacks, fn.callback)
+	lost := f.err != nil
+	f.lock.Unlock()
+	if !ok || lost {
+		return
+	}
+	f.roundTrip(message{Op: opRelease, Index: fn.callback})
+}
+
+func (fn functionValue) wire() *wireValue {
+	return &wireValue{Type: typeFunction, ID: fn.h.id}
+}
+
+func (fn functionValue) String() string {
+	return fmt.Sprintf("[function #%d]", fn.h.id)
+}
+
+var _ driver.Function = functionValue{}
+
+// exception is the panic value used when JavaScript throws.
+type exception struct {
+	value driver.Value
+}
+
+func (e exception) Error() string {
+	return driver.NewError(e.value).Error()
+}
+
+func recoverException(err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	e, ok := p.(exception)
+	if !ok {
+		panic(p)
+	}
+	*err = driver.NewError(e.value)
+}
+
+type empty struct{}
+
+func (e empty) IsUndefined() bool {
+	return false
+}
+
+func (e empty) IsNull() bool {
+	return false
+}
+
+func (e empty) ToBoolean() (bool, bool) {
+	return false, false
+}
+
+func (e empty) ToFloat64() (float64, bool) {
+	return 0, false
+}
+
+func (e empty) ToString() (string, bool) {
+	return "", false
+}
+
+func (e empty) ToObject() (driver.Object, bool) {
+	return nil, false
+}
+
+func (e empty) ToFunction() (driver.Function, bool) {
+	return nil, false
+}
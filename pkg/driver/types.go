@@ -9,13 +9,20 @@ type (
 		Boolean(t bool) Value
 		Number(f float64) Value
 		String(s string) Value
+		// Function wraps a Go function so it can be called from JavaScript.
+		// It must be released once JavaScript no longer holds on to it.
 		Function(f func(this Object, args ...Value) Value) Function
 		Buffer(size int) Buffer
 		Array(values ...Value) Object
+		// LiveCallbacks returns the number of functions created by Function that have not yet been released.
+		// It is meant for detecting leaks.
+		LiveCallbacks() int
 	}
 	Value interface {
 		IsUndefined() (ok bool)
 		IsNull() (ok bool)
+		// TypeOf returns the result of JavaScript's typeof operator; "object" for null.
+		TypeOf() string
 
 		ToBoolean() (value, ok bool)
 		ToFloat64() (value float64, ok bool)
@@ -27,16 +34,39 @@ type (
 		Value
 		Get(key string) Value
 		Set(key string, value Value)
+		// Has returns true if the property is present on the object or its prototype chain,
+		// even if its value is undefined.
+		Has(key string) bool
+		Delete(key string)
+		// Keys returns the names of the object's own enumerable properties, like Object.keys.
+		Keys() []string
+		Index(i int) Value
+		SetIndex(i int, value Value)
+		InstanceOf(constructor Function) bool
 	}
 	Function interface {
 		Value
 		New(args ...Value) Object
 		Call(this Object, args ...Value) Value
+		// NewErr is like New, but returns a thrown exception as an *Error instead of panicking.
+		NewErr(args ...Value) (Object, error)
+		// CallErr is like Call, but returns a thrown exception as an *Error instead of panicking.
+		CallErr(this Object, args ...Value) (Value, error)
+		// Release frees a function created by Factory.Function; it must not be called again afterwards.
+		// Releasing more than once, or releasing any other function, does nothing.
+		Release()
 	}
 	Buffer interface {
 		Size() int
 		Put(data []byte) int
 		Get(data []byte) int
+		// Slice returns a buffer sharing the given range of bytes with this one.
+		Slice(byteOffset, byteLength int) Buffer
+		// View returns a typed array of length elements, starting at byteOffset.
+		// The byte offset must be a multiple of the element size.
+		View(t ArrayType, byteOffset, length int) Object
+		// As returns a typed array over the entire buffer.
+		As(t ArrayType) Object
 		AsUint8Array() Object
 		AsUint16Array() Object
 		AsFloat32Array() Object
@@ -1,7 +1,7 @@
 package wasmjs
 
 import (
-	"fmt"
+	"sync/atomic"
 	"syscall/js"
 
 	"github.com/PieterD/warp/pkg/driver"
@@ -23,13 +23,10 @@ func (j jsFactory) Equal(v1, v2 driver.Value) (equal bool) {
 func (j jsFactory) Array(values ...driver.Value) driver.Object {
 	var jsValues []interface{}
 	for _, value := range values {
-		vv, ok := value.(vValue)
-		if !ok {
-			panic(fmt.Errorf("non-wasmjs value: %T %v", value, value))
-		}
-		jsValues = append(jsValues, vv.jsValue())
+		jsValues = append(jsValues, value2js(value))
 	}
-	jsArrayObject := js.Global().Get("Array").New(jsValues...)
+	// Array.of, unlike the Array constructor, does not treat a single number as the length.
+	jsArrayObject := js.Global().Get("Array").Call("of", jsValues...)
 	return jsObject{
 		v: jsArrayObject,
 	}
@@ -42,7 +39,7 @@ func (j jsFactory) Boolean(t bool) driver.Value {
 }
 
 func (j jsFactory) Buffer(size int) driver.Buffer {
-	return newBuffer(j, size)
+	return newBuffer(size)
 }
 
 func (j jsFactory) Global() driver.Object {
@@ -76,16 +73,25 @@ func (j jsFactory) String(s string) driver.Value {
 }
 
 func (j jsFactory) Function(f func(this driver.Object, args ...driver.Value) driver.Value) driver.Function {
+	fn := js.FuncOf(func(jsThis js.Value, jsArgs []js.Value) interface{} {
+		var vArgs []driver.Value
+		for _, arg := range jsArgs {
+			vArgs = append(vArgs, js2value(arg))
+		}
+		rv := f(jsObject{v: jsThis}, vArgs...)
+		return value2js(rv)
+	})
+	atomic.AddInt64(&liveCallbacks, 1)
 	return jsFunction{
-		v: js.ValueOf(js.FuncOf(func(jsThis js.Value, jsArgs []js.Value) interface{} {
-			var vArgs []driver.Value
-			for _, arg := range jsArgs {
-				vArgs = append(vArgs, js2value(arg))
-			}
-			rv := f(jsObject{v: jsThis}, vArgs...)
-			return value2js(rv)
-		})),
+		v: js.ValueOf(fn),
+		callback: &jsCallback{
+			fn: fn,
+		},
 	}
 }
 
+func (j jsFactory) LiveCallbacks() int {
+	return int(atomic.LoadInt64(&liveCallbacks))
+}
+
 var _ driver.Factory = jsFactory{}
@@ -2,6 +2,7 @@ package wasmjs
 
 import (
 	"fmt"
+	"sync/atomic"
 	"syscall/js"
 
 	"github.com/PieterD/warp/pkg/driver"
@@ -20,6 +21,10 @@ func (j jsUndefined) IsUndefined() bool {
 	return true
 }
 
+func (j jsUndefined) TypeOf() string {
+	return "undefined"
+}
+
 var _ vValue = jsUndefined{}
 
 type jsNull struct {
@@ -35,6 +40,10 @@ func (j jsNull) IsNull() bool {
 	return true
 }
 
+func (j jsNull) TypeOf() string {
+	return "object"
+}
+
 var _ vValue = jsNull{}
 
 type jsBoolean struct {
@@ -46,6 +55,10 @@ func (j jsBoolean) jsValue() js.Value {
 	return j.v
 }
 
+func (j jsBoolean) TypeOf() string {
+	return "boolean"
+}
+
 func (j jsBoolean) ToBoolean() (bool, bool) {
 	switch jsType := j.v.Type(); jsType {
 	case js.TypeBoolean:
@@ -66,6 +79,10 @@ func (j jsNumber) jsValue() js.Value {
 	return j.v
 }
 
+func (j jsNumber) TypeOf() string {
+	return "number"
+}
+
 func (j jsNumber) ToFloat64() (float64, bool) {
 	switch jsType := j.v.Type(); jsType {
 	case js.TypeNumber:
@@ -86,6 +103,10 @@ func (j jsString) jsValue() js.Value {
 	return j.v
 }
 
+func (j jsString) TypeOf() string {
+	return "string"
+}
+
 func (j jsString) ToString() (string, bool) {
 	switch jsType := j.v.Type(); jsType {
 	case js.TypeString:
@@ -106,6 +127,10 @@ func (j jsObject) jsValue() js.Value {
 	return j.v
 }
 
+func (j jsObject) TypeOf() string {
+	return "object"
+}
+
 func (j jsObject) ToObject() (driver.Object, bool) {
 	switch jsType := j.v.Type(); jsType {
 	case js.TypeObject:
@@ -125,15 +150,58 @@ func (j jsObject) Set(key string, value driver.Value) {
 	j.v.Set(key, value2js(value))
 }
 
+func (j jsObject) Has(key string) bool {
+	return js.Global().Get("Reflect").Call("has", j.v, key).Bool()
+}
+
+func (j jsObject) Delete(key string) {
+	j.v.Delete(key)
+}
+
+func (j jsObject) Keys() []string {
+	jsKeys := js.Global().Get("Object").Call("keys", j.v)
+	keys := make([]string, jsKeys.Length())
+	for i := range keys {
+		keys[i] = jsKeys.Index(i).String()
+	}
+	return keys
+}
+
+func (j jsObject) Index(i int) driver.Value {
+	return js2value(j.v.Index(i))
+}
+
+func (j jsObject) SetIndex(i int, value driver.Value) {
+	j.v.SetIndex(i, value2js(value))
+}
+
+func (j jsObject) InstanceOf(constructor driver.Function) bool {
+	return j.v.InstanceOf(value2js(constructor))
+}
+
 type jsFunction struct {
 	jsEmpty
 	v js.Value
+	// callback is only set for functions created by jsFactory.Function.
+	callback *jsCallback
+}
+
+// liveCallbacks counts the jsCallbacks that have not been released.
+var liveCallbacks int64
+
+type jsCallback struct {
+	fn       js.Func
+	released int32
 }
 
 func (j jsFunction) jsValue() js.Value {
 	return j.v
 }
 
+func (j jsFunction) TypeOf() string {
+	return "function"
+}
+
 func (j jsFunction) ToFunction() (driver.Function, bool) {
 	switch jsType := j.v.Type(); jsType {
 	case js.TypeFunction:
@@ -157,11 +225,16 @@ func (j jsFunction) New(args ...driver.Value) driver.Object {
 }
 
 func (j jsFunction) Call(this driver.Object, args ...driver.Value) driver.Value {
-	vThis, ok := this.(vValue)
-	if !ok {
-		panic(fmt.Errorf("unknown this type: %T", this))
+	// A nil this is passed as undefined, which makes non-strict functions use the global object.
+	jsThis := js.Undefined()
+	if this != nil {
+		vThis, ok := this.(vValue)
+		if !ok {
+			panic(fmt.Errorf("unknown this type: %T", this))
+		}
+		jsThis = vThis.jsValue()
 	}
-	jsArgs := []interface{}{vThis.jsValue()}
+	jsArgs := []interface{}{jsThis}
 	for _, arg := range args {
 		jsArgs = append(jsArgs, value2js(arg))
 	}
@@ -169,42 +242,87 @@ func (j jsFunction) Call(this driver.Object, args ...driver.Value) driver.Value
 	return js2value(jsReturn)
 }
 
+func (j jsFunction) NewErr(args ...driver.Value) (obj driver.Object, err error) {
+	defer recoverError(&err)
+	return j.New(args...), nil
+}
+
+func (j jsFunction) CallErr(this driver.Object, args ...driver.Value) (rv driver.Value, err error) {
+	defer recoverError(&err)
+	return j.Call(this, args...), nil
+}
+
+func (j jsFunction) Release() {
+	if j.callback == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&j.callback.released, 0, 1) {
+		return
+	}
+	j.callback.fn.Release()
+	atomic.AddInt64(&liveCallbacks, -1)
+}
+
+// recoverError turns a panic caused by a JavaScript exception into an error.
+// Any other panic is passed on.
+func recoverError(err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	jsErr, ok := p.(js.Error)
+	if !ok {
+		panic(p)
+	}
+	*err = driver.NewError(js2value(jsErr.Value))
+}
+
 type jsBuffer struct {
 	jsEmpty
-	factory driver.Factory
-	v       js.Value
-	obj     driver.Object
+	v js.Value
+	// byteOffset is the offset of v into its ArrayBuffer, size is its length.
+	byteOffset int
+	size       int
 }
 
 func (j jsBuffer) jsValue() js.Value {
 	return j.v
 }
 
-func newBuffer(factory jsFactory, size int) jsBuffer {
-	fUint8Array, ok := factory.Global().Get("Uint8Array").ToFunction()
-	if !ok {
-		panic(fmt.Errorf("Uint8Array constructor is missing"))
+func (j jsBuffer) TypeOf() string {
+	return "object"
+}
+
+func newBuffer(size int) jsBuffer {
+	obj := typedArrayConstructor(driver.Uint8Array).New(size)
+	return jsBuffer{
+		v:          obj,
+		byteOffset: 0,
+		size:       size,
 	}
-	obj := fUint8Array.New(factory.Number(float64(size)))
-	vObj, ok := obj.(vValue)
-	if !ok {
-		panic(fmt.Errorf("buffer object was somehow not an object"))
+}
+
+var typedArrayConstructors [driver.Float64Array + 1]js.Value
+
+func typedArrayConstructor(t driver.ArrayType) js.Value {
+	if t < driver.Int8Array || t > driver.Float64Array {
+		panic(fmt.Errorf("invalid ArrayType: %v", t))
 	}
-	return jsBuffer{
-		factory: factory,
-		v:       vObj.jsValue(),
-		obj:     obj,
+	con := typedArrayConstructors[t]
+	if con.IsUndefined() {
+		con = js.Global().Get(t.String())
+		if con.Type() != js.TypeFunction {
+			panic(fmt.Errorf("%s was not a function", t))
+		}
+		typedArrayConstructors[t] = con
 	}
+	return con
 }
 
 var _ vValue = jsBuffer{}
 
 func (j jsBuffer) Size() int {
-	length, ok := j.obj.Get("length").ToFloat64()
-	if !ok {
-		panic(fmt.Errorf("buffer length was not a number"))
-	}
-	return int(length)
+	return j.size
 }
 
 func (j jsBuffer) Put(data []byte) int {
@@ -215,28 +333,43 @@ func (j jsBuffer) Get(data []byte) int {
 	return js.CopyBytesToGo(data, j.v)
 }
 
-func (j jsBuffer) AsUint8Array() driver.Object {
-	con, ok := j.factory.Global().Get("Uint8Array").ToFunction()
-	if !ok {
-		panic(fmt.Errorf("Uint8Array was not a function"))
+func (j jsBuffer) Slice(byteOffset, byteLength int) driver.Buffer {
+	driver.CheckView(j.byteOffset, j.size, driver.Uint8Array, byteOffset, byteLength)
+	obj := j.v.Call("subarray", byteOffset, byteOffset+byteLength)
+	return jsBuffer{
+		v:          obj,
+		byteOffset: j.byteOffset + byteOffset,
+		size:       byteLength,
 	}
-	return con.New(j.obj.Get("buffer"))
 }
 
-func (j jsBuffer) AsUint16Array() driver.Object {
-	con, ok := j.factory.Global().Get("Uint16Array").ToFunction()
-	if !ok {
-		panic(fmt.Errorf("Uint16Array was not a function"))
+func (j jsBuffer) View(t driver.ArrayType, byteOffset, length int) driver.Object {
+	driver.CheckView(j.byteOffset, j.size, t, byteOffset, length)
+	if t == driver.Uint8Array && byteOffset == 0 && length == j.size {
+		return jsObject{
+			v: j.v,
+		}
 	}
-	return con.New(j.obj.Get("buffer"))
+	obj := typedArrayConstructor(t).New(j.v.Get("buffer"), j.byteOffset+byteOffset, length)
+	return jsObject{
+		v: obj,
+	}
+}
+
+func (j jsBuffer) As(t driver.ArrayType) driver.Object {
+	return j.View(t, 0, j.size/t.BytesPerElement())
+}
+
+func (j jsBuffer) AsUint8Array() driver.Object {
+	return j.As(driver.Uint8Array)
+}
+
+func (j jsBuffer) AsUint16Array() driver.Object {
+	return j.As(driver.Uint16Array)
 }
 
 func (j jsBuffer) AsFloat32Array() driver.Object {
-	con, ok := j.factory.Global().Get("Float32Array").ToFunction()
-	if !ok {
-		panic(fmt.Errorf("Float32Array was not a function"))
-	}
-	return con.New(j.obj.Get("buffer"))
+	return j.As(driver.Float32Array)
 }
 
 type jsEmpty struct{}
@@ -268,5 +401,3 @@ func (j jsEmpty) ToObject() (optionalValue driver.Object, ok bool) {
 func (j jsEmpty) ToFunction() (optionalValue driver.Function, ok bool) {
 	return nil, ok
 }
-
-var _ driver.Value = jsEmpty{}
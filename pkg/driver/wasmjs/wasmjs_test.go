@@ -0,0 +1,11 @@
+package wasmjs
+
+import (
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver/drivertest"
+)
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, Open(), drivertest.Config{})
+}
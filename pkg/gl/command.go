@@ -0,0 +1,234 @@
+package gl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// maxCommandBytes is the size at which a command buffer flushes itself.
+const maxCommandBytes = 64 * 1024
+
+// CommandBufferStats describes the work done by a Context's command buffer.
+type CommandBufferStats struct {
+	// Commands is the number of GL calls that were recorded instead of made directly.
+	Commands int
+	// Flushes is the number of times the recorded calls were replayed in JavaScript.
+	Flushes int
+	// Bytes is the combined size of the replayed command streams.
+	Bytes int
+}
+
+// Command stream argument tags.
+const (
+	commandFloat64 = iota
+	commandInt32
+	commandFalse
+	commandTrue
+	commandNull
+	commandUndefined
+	commandValue
+)
+
+// commandReplaySource is the body of a JavaScript function that takes a GL context and the names of the
+// recordable functions, and returns a function replaying a command stream.
+//
+// Every command is an uint16 function index and an uint8 argument count, followed by the arguments.
+// Every argument is an uint8 tag, followed by a little endian float64 or int32 for numbers,
+// or an uint32 index into the values array for anything that is not a number, boolean or null.
+const commandReplaySource = `
+const fns = names.map((name) => gl[name]);
+const args = [];
+return function (bytes, length, values) {
+    const view = new DataView(bytes.buffer, bytes.byteOffset, length);
+    let p = 0;
+    while (p < length) {
+        const fn = fns[view.getUint16(p, true)];
+        const argc = view.getUint8(p + 2);
+        p += 3;
+        args.length = argc;
+        for (let i = 0; i < argc; i++) {
+            const tag = view.getUint8(p);
+            p += 1;
+            switch (tag) {
+                case 0: args[i] = view.getFloat64(p, true); p += 8; break;
+                case 1: args[i] = view.getInt32(p, true); p += 4; break;
+                case 2: args[i] = false; break;
+                case 3: args[i] = true; break;
+                case 4: args[i] = null; break;
+                case 5: args[i] = undefined; break;
+                case 6: args[i] = values[view.getUint32(p, true)]; p += 4; break;
+                default: throw new Error("invalid command stream tag: " + tag);
+            }
+        }
+        fn.apply(gl, args);
+    }
+};
+`
+
+// commandBuffer records GL calls that return nothing and do not read or write client memory,
+// and replays them in a single JavaScript call when flushed.
+// Any other call flushes the buffer first, so calls are always made in order.
+type commandBuffer struct {
+	factory driver.Factory
+	names   []string
+	replay  driver.Function
+	data    []byte
+	values  []driver.Value
+	stats   CommandBufferStats
+	// buffer and array hold the stream while it is replayed.
+	// They are not shared with the staging pool, because a flush can happen in the middle of an upload.
+	buffer driver.Buffer
+	array  driver.Object
+}
+
+func newCommandBuffer(factory driver.Factory) *commandBuffer {
+	return &commandBuffer{
+		factory: factory,
+	}
+}
+
+// compile creates the replay function in JavaScript.
+// It returns false if the driver has no Function constructor to do so.
+func (commands *commandBuffer) compile(obj driver.Object) bool {
+	constructor, ok := commands.factory.Global().Get("Function").ToFunction()
+	if !ok {
+		return false
+	}
+	// Function can be called without new, and unlike New, Call returns the created function as a Function.
+	makeReplay, err := constructor.CallErr(nil, commands.factory.String("gl"), commands.factory.String("names"), commands.factory.String(commandReplaySource))
+	if err != nil {
+		return false
+	}
+	makeReplayFunction, ok := makeReplay.ToFunction()
+	if !ok {
+		return false
+	}
+	var names []driver.Value
+	for _, name := range commands.names {
+		names = append(names, commands.factory.String(name))
+	}
+	replay, ok := makeReplayFunction.Call(nil, obj, commands.factory.Array(names...)).ToFunction()
+	if !ok {
+		panic(fmt.Errorf("command replay function was not created"))
+	}
+	commands.replay = replay
+	return true
+}
+
+// register adds a function to the set of recordable functions, and returns its index.
+func (commands *commandBuffer) register(functionName string) int {
+	commands.names = append(commands.names, functionName)
+	return len(commands.names) - 1
+}
+
+// recorder returns a replacement for a recordable function.
+func (commands *commandBuffer) recorder(index int, direct func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
+	return func(args ...driver.Value) driver.Value {
+		if commands.replay == nil {
+			return direct(args...)
+		}
+		commands.record(index, args)
+		return commands.factory.Undefined()
+	}
+}
+
+// flusher returns a replacement for a function that can not be recorded, which flushes before calling it.
+func (commands *commandBuffer) flusher(direct func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
+	return func(args ...driver.Value) driver.Value {
+		commands.flush()
+		return direct(args...)
+	}
+}
+
+func (commands *commandBuffer) record(index int, args []driver.Value) {
+	if len(args) > math.MaxUint8 {
+		panic(fmt.Errorf("too many arguments for command %s: %d", commands.names[index], len(args)))
+	}
+	data := commands.data
+	data = append(data, 0, 0, byte(len(args)))
+	binary.LittleEndian.PutUint16(data[len(data)-3:], uint16(index))
+	for _, arg := range args {
+		data = commands.appendArg(data, arg)
+	}
+	commands.data = data
+	commands.stats.Commands++
+	if len(commands.data) >= maxCommandBytes {
+		commands.flush()
+	}
+}
+
+func (commands *commandBuffer) appendArg(data []byte, arg driver.Value) []byte {
+	if arg == nil || arg.IsNull() {
+		return append(data, commandNull)
+	}
+	if f, ok := arg.ToFloat64(); ok {
+		if i := int32(f); float64(i) == f && (i != 0 || !math.Signbit(f)) {
+			data = append(data, commandInt32, 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(data[len(data)-4:], uint32(i))
+			return data
+		}
+		data = append(data, commandFloat64, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint64(data[len(data)-8:], math.Float64bits(f))
+		return data
+	}
+	if b, ok := arg.ToBoolean(); ok {
+		if b {
+			return append(data, commandTrue)
+		}
+		return append(data, commandFalse)
+	}
+	if arg.IsUndefined() {
+		return append(data, commandUndefined)
+	}
+	commands.values = append(commands.values, arg)
+	data = append(data, commandValue, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(data[len(data)-4:], uint32(len(commands.values)-1))
+	return data
+}
+
+// flush replays the recorded commands, if there are any.
+func (commands *commandBuffer) flush() {
+	if len(commands.data) == 0 {
+		return
+	}
+	if commands.buffer == nil || commands.buffer.Size() < len(commands.data) {
+		size := minStagingSize
+		for size < len(commands.data) {
+			size *= 2
+		}
+		commands.buffer = commands.factory.Buffer(size)
+		commands.array = commands.buffer.AsUint8Array()
+	}
+	commands.buffer.Put(commands.data)
+	var values driver.Value = commands.factory.Undefined()
+	if len(commands.values) > 0 {
+		values = commands.factory.Array(commands.values...)
+	}
+	length := len(commands.data)
+	commands.stats.Flushes++
+	commands.stats.Bytes += length
+	commands.discard()
+	commands.replay.Call(nil, commands.array, commands.factory.Number(float64(length)), values)
+}
+
+// discard drops the recorded commands without replaying them.
+func (commands *commandBuffer) discard() {
+	commands.data = commands.data[:0]
+	for i := range commands.values {
+		commands.values[i] = nil
+	}
+	commands.values = commands.values[:0]
+}
+
+// CommandBufferStats returns the command buffer statistics.
+// They are all zero if the Context was not created with ContextConfig.CommandBuffer,
+// or if the driver could not run a command buffer.
+func (glx *Context) CommandBufferStats() CommandBufferStats {
+	if glx.commands == nil {
+		return CommandBufferStats{}
+	}
+	return glx.commands.stats
+}
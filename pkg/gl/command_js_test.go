@@ -0,0 +1,162 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/wasmjs"
+)
+
+type jsCanvas struct {
+	factory driver.Factory
+	obj     driver.Object
+}
+
+func (c jsCanvas) Driver() (factory driver.Factory, obj driver.Object) {
+	return c.factory, c.obj
+}
+
+// fakeGlSource creates a canvas with a fake WebGL2 context, like newFakeCanvas, but in JavaScript,
+// so that the command buffer's replay function can run against it.
+// If record is set, every call other than create* is logged in the context's calls array.
+const fakeGlSource = `
+const gl = { calls: [] };
+const ids = new Map();
+const show = (v) => {
+    if (ids.has(v)) return "#" + ids.get(v);
+    if (ArrayBuffer.isView(v)) return "[" + Array.from(v).join(" ") + "]";
+    if (Object.is(v, -0)) return "-0";
+    return String(v);
+};
+constants.forEach((name, i) => { gl[name] = 0x1000 + i; });
+functions.forEach((name) => {
+    if (name.startsWith("create")) {
+        gl[name] = function () { const object = {}; ids.set(object, ids.size); return object; };
+    } else if (record) {
+        gl[name] = function (...args) { gl.calls.push(name + "(" + args.map(show).join(", ") + ")"); };
+    } else {
+        gl[name] = function () {};
+    }
+});
+return { getContext: (name) => name === "webgl2" ? gl : null };
+`
+
+func newJsCanvas(record bool) jsCanvas {
+	factory := wasmjs.Open()
+	var constants, functions []driver.Value
+	t := reflect.TypeOf(glConstants{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.Func {
+			constants = append(constants, factory.String(field.Name))
+			continue
+		}
+		functions = append(functions, factory.String(strings.ToLower(field.Name[:1])+field.Name[1:]))
+	}
+	constructor, _ := factory.Global().Get("Function").ToFunction()
+	makeCanvas, _ := constructor.Call(nil, factory.String("constants"), factory.String("functions"), factory.String("record"), factory.String(fakeGlSource)).ToFunction()
+	canvasObj, _ := makeCanvas.Call(nil, factory.Array(constants...), factory.Array(functions...), factory.Boolean(record)).ToObject()
+	return jsCanvas{
+		factory: factory,
+		obj:     canvasObj,
+	}
+}
+
+func (c jsCanvas) calls() []string {
+	glObj, _ := driver.Bind(c.obj, "getContext")(c.factory.String("webgl2")).ToObject()
+	callsObj, _ := glObj.Get("calls").ToObject()
+	joined, _ := driver.Bind(callsObj, "join")(c.factory.String("\n")).ToString()
+	return strings.Split(joined, "\n")
+}
+
+func drawCommandFrame(glx *Context, draws int) {
+	buffer := glx.CreateBuffer()
+	vao := glx.CreateVertexArray()
+	glx.BindVertexArray(vao)
+	glx.Targets().Array().BindBuffer(buffer)
+	glx.Targets().Array().BufferData([]byte{1, 2, 3, 4}, Static, Draw)
+	vao.VertexAttribPointer(0, Float, false, 0, 0)
+	vao.EnableVertexAttribArray(0)
+	glx.Viewport(0, 0, 640, 480)
+	glx.ClearColor(0.25, 0.1, 1e-10, 1)
+	glx.Clear()
+	glx.Features().Blend(true)
+	glx.UnuseProgram()
+	for i := 0; i < draws; i++ {
+		glx.DrawArrays(Triangles, i, 3)
+	}
+	glx.UnbindVertexArray()
+	glx.Flush()
+}
+
+func TestCommandBuffer(t *testing.T) {
+	directCanvas := newJsCanvas(true)
+	direct := newTestContext(t, directCanvas, ContextConfig{})
+	drawCommandFrame(direct, 10000)
+
+	commandCanvas := newJsCanvas(true)
+	commands := newTestContext(t, commandCanvas, ContextConfig{CommandBuffer: true})
+	drawCommandFrame(commands, 10000)
+
+	want, got := directCanvas.calls(), commandCanvas.calls()
+	if len(want) != len(got) {
+		t.Fatalf("expected %d calls, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("expected call %d to be %s, got: %s", i, want[i], got[i])
+		}
+	}
+	if stats := direct.CommandBufferStats(); stats != (CommandBufferStats{}) {
+		t.Fatalf("expected no command buffer stats without a command buffer, got: %+v", stats)
+	}
+	stats := commands.CommandBufferStats()
+	// Flush and bufferData are not recorded.
+	if stats.Commands != len(got)-2 {
+		t.Fatalf("expected %d recorded commands, got: %d", len(got)-2, stats.Commands)
+	}
+	// One flush before bufferData, at least two for the size limit, and one at the end of the frame.
+	if stats.Flushes < 4 || stats.Flushes > 10 {
+		t.Fatalf("unexpected number of flushes: %d", stats.Flushes)
+	}
+}
+
+func TestCommandBufferContextLost(t *testing.T) {
+	canvas := newJsCanvas(true)
+	glx := newTestContext(t, canvas, ContextConfig{CommandBuffer: true})
+	glx.Viewport(0, 0, 640, 480)
+	glx.contextLost()
+	glx.Flush()
+	for _, call := range canvas.calls() {
+		if strings.HasPrefix(call, "viewport") {
+			t.Fatalf("expected the recorded commands to be dropped when the context is lost, got: %s", call)
+		}
+	}
+}
+
+func benchmarkCommandFrames(b *testing.B, cfg ContextConfig) {
+	glx := newTestContext(b, newJsCanvas(false), cfg)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		glx.Viewport(0, 0, 640, 480)
+		glx.ClearColor(0.25, 0.5, 0.75, 1)
+		glx.Clear()
+		for j := 0; j < 100; j++ {
+			glx.DrawArrays(Triangles, j, 3)
+		}
+		glx.Flush()
+	}
+}
+
+func BenchmarkFrameDirect(b *testing.B) {
+	benchmarkCommandFrames(b, ContextConfig{})
+}
+
+func BenchmarkFrameCommandBuffer(b *testing.B) {
+	benchmarkCommandFrames(b, ContextConfig{CommandBuffer: true})
+}
@@ -17,35 +17,180 @@ type Context struct {
 	obj           driver.Object
 	constants     glConstants
 	typeConverter *typeConverter
+	staging       *stagingPool
+	commands      *commandBuffer
+	degraded      []string
+	webgl1        bool
+	loss          contextLoss
+	binding       glBinding
+	state         *stateCache
 }
 
-func NewContext(canvas Canvas) *Context {
+type PowerPreference string
+
+const (
+	PowerDefault         PowerPreference = "default"
+	PowerHighPerformance PowerPreference = "high-performance"
+	PowerLowPower        PowerPreference = "low-power"
+)
+
+// ContextAttributes are passed to getContext, and configure the drawing buffer.
+type ContextAttributes struct {
+	Alpha                        bool
+	Depth                        bool
+	Stencil                      bool
+	Antialias                    bool
+	PremultipliedAlpha           bool
+	PreserveDrawingBuffer        bool
+	PowerPreference              PowerPreference `js:"powerPreference,optional"`
+	FailIfMajorPerformanceCaveat bool
+}
+
+// DefaultContextAttributes returns the attributes a browser uses when none are given.
+func DefaultContextAttributes() ContextAttributes {
+	return ContextAttributes{
+		Alpha:              true,
+		Depth:              true,
+		Antialias:          true,
+		PremultipliedAlpha: true,
+		PowerPreference:    PowerDefault,
+	}
+}
+
+// ContextConfig holds the options for NewContextConfig.
+type ContextConfig struct {
+	// CommandBuffer records GL calls that return nothing into a binary stream,
+	// and replays them in a single JavaScript call when a result is needed or Flush is called.
+	// This saves the cost of crossing from Go into JavaScript for every call,
+	// but requires Flush to be called at the end of every frame.
+	// It is ignored if the driver can not compile JavaScript functions.
+	CommandBuffer bool
+	// Trace receives every GL call made through the Context.
+	// Tracing is disabled if it is nil.
+	Trace TraceSink
+	// Debug enables checking getError after every GL call, and is called for every reported error.
+	// Panicking in Debug stops at the first error, with the offending call on the stack.
+	// It makes the command buffer flush after every call.
+	Debug func(err *GLError)
+	// Attributes are passed to getContext; if nil, the browser defaults are used.
+	Attributes *ContextAttributes
+	// WebGL1Fallback creates a WebGL1 context if WebGL2 is not available.
+	// Missing WebGL2 functions are taken from WebGL1 extensions where possible;
+	// Degraded reports the features that are not, and using them panics.
+	WebGL1Fallback bool
+	// DisableStateCache issues every binding and enable call, even if it would not change the GL state.
+	// This is useful when debugging, or when GL state is changed without going through the Context.
+	DisableStateCache bool
+}
+
+func NewContext(canvas Canvas) (*Context, error) {
+	return NewContextConfig(canvas, ContextConfig{})
+}
+
+func NewContextConfig(canvas Canvas, cfg ContextConfig) (*Context, error) {
 	factory, canvasObject := canvas.Driver()
-	fGetContext := driver.Bind(canvasObject, "getContext")
-	ctxObject, ok := fGetContext(factory.String("webgl2")).ToObject()
+	getContext, err := driver.BindErr(canvasObject, "getContext")
+	if err != nil {
+		return nil, fmt.Errorf("binding getContext: %w", err)
+	}
+	attributes := factory.Undefined()
+	if cfg.Attributes != nil {
+		attributes, err = driver.Marshal(factory, *cfg.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("converting context attributes: %w", err)
+		}
+	}
+	ctxValue, err := getContext(factory.String("webgl2"), attributes)
+	if err != nil {
+		return nil, fmt.Errorf("creating WebGL2 context: %w", err)
+	}
+	ctxObject, ok := ctxValue.ToObject()
+	var webgl1 *webgl1Fallback
 	if !ok {
-		return nil
+		if !cfg.WebGL1Fallback {
+			return nil, fmt.Errorf("WebGL2 is not supported, or not with the given context attributes")
+		}
+		ctxValue, err = getContext(factory.String("webgl"), attributes)
+		if err != nil {
+			return nil, fmt.Errorf("creating WebGL1 context: %w", err)
+		}
+		ctxObject, ok = ctxValue.ToObject()
+		if !ok {
+			return nil, fmt.Errorf("neither WebGL2 nor WebGL1 is supported, or not with the given context attributes")
+		}
+		webgl1 = newWebgl1Fallback(factory, ctxObject)
 	}
 
-	constants := newGlConstants(ctxObject, false)
+	var commands *commandBuffer
+	if cfg.CommandBuffer {
+		commands = newCommandBuffer(factory)
+	}
+	var trace *tracer
+	if cfg.Trace != nil {
+		trace = newTracer(factory, cfg.Trace)
+	}
+	var debug *debugger
+	if cfg.Debug != nil {
+		getError := driver.Bind(ctxObject, "getError")
+		if getError == nil {
+			return nil, fmt.Errorf("missing getError")
+		}
+		if commands != nil {
+			getError = commands.flusher(getError)
+		}
+		debug = newDebugger(ctxObject, getError, cfg.Debug)
+	}
+	binding := glBinding{
+		trace:    trace,
+		commands: commands,
+		debug:    debug,
+		webgl1:   webgl1,
+	}
+	constants := newGlConstants(ctxObject, binding)
+	if commands != nil && !commands.compile(ctxObject) {
+		commands = nil
+	}
 	typeConverter := newTypeConverter(constants)
 	glx := &Context{
 		factory:       factory,
 		obj:           ctxObject,
 		constants:     constants,
 		typeConverter: typeConverter,
+		staging:       newStagingPool(factory),
+		commands:      commands,
+		binding:       binding,
+		state:         newStateCache(factory, cfg.DisableStateCache),
+	}
+	if webgl1 != nil {
+		glx.webgl1 = true
+		glx.degraded = webgl1.degradedFeatures()
 	}
-	return glx
+	glx.listenContextLoss(canvasObject)
+	return glx, nil
+}
+
+// IsWebGL1 returns true if the Context fell back to WebGL1.
+func (glx *Context) IsWebGL1() bool {
+	return glx.webgl1
 }
 
+// Degraded returns the names of the features that are unavailable because the Context fell back to WebGL1.
+func (glx *Context) Degraded() []string {
+	return append([]string(nil), glx.degraded...)
+}
+
+// Destroy loses the context on purpose, which releases its resources.
+// Restore callbacks are not called afterwards.
 func (glx *Context) Destroy() {
-	getExtension := driver.Bind(glx.obj, "getExtension")
-	if getExtension == nil {
-		panic(fmt.Errorf("missing getExtension"))
+	if glx.loss.deregister != nil {
+		glx.loss.deregister()
+		glx.loss.deregister = nil
 	}
-	extension := getExtension(glx.factory.String("WEBGL_lose_context"))
-	extensionObject, ok := extension.ToObject()
-	if !ok {
+	glx.loss.lock.Lock()
+	glx.loss.lost = true
+	glx.loss.lock.Unlock()
+	extensionObject := glx.loseContextExtension()
+	if extensionObject == nil {
 		return
 	}
 	loseContext := driver.Bind(extensionObject, "loseContext")
@@ -59,6 +204,7 @@ func (glx *Context) Log(f string, args ...interface{}) {
 	driver.Log(glx.factory, glx.factory.String(fmt.Sprintf(f, args...)))
 }
 
+// Flush also replays any calls recorded by the command buffer.
 func (glx *Context) Flush() {
 	glx.constants.Flush()
 }
@@ -68,11 +214,11 @@ func (glx *Context) Finish() {
 }
 
 func (glx *Context) UseProgram(program ProgramObject) {
-	glx.constants.UseProgram(program.value)
+	glx.useProgram(program.value)
 }
 
 func (glx *Context) UnuseProgram() {
-	glx.constants.UseProgram(glx.factory.Null())
+	glx.useProgram(glx.factory.Null())
 }
 
 func (glx *Context) CreateProgram() ProgramObject {
@@ -97,6 +243,7 @@ func (glx *Context) CreateShader(shaderType ShaderType) ShaderObject {
 	return ShaderObject{
 		glx:   glx,
 		value: value,
+		typ:   shaderType,
 	}
 }
 
@@ -134,6 +281,7 @@ func (glx *Context) CreateBuffer() BufferObject {
 func (buffer BufferObject) Destroy() {
 	glx := buffer.glx
 	glx.constants.DeleteBuffer(buffer.value)
+	glx.state.forget(buffer.value)
 }
 
 type VertexArrayObject struct {
@@ -152,6 +300,7 @@ func (glx *Context) CreateVertexArray() VertexArrayObject {
 func (vao VertexArrayObject) Destroy() {
 	glx := vao.glx
 	glx.constants.DeleteVertexArray(vao.value)
+	glx.state.forget(vao.value)
 }
 
 // A buffer must be bound to the ARRAY_BUFFER target.
@@ -190,11 +339,11 @@ func (vao VertexArrayObject) VertexAttribDivisor(attrIndex int, divisor int) {
 }
 
 func (glx *Context) BindVertexArray(vao VertexArrayObject) {
-	glx.constants.BindVertexArray(vao.value)
+	glx.bindVertexArray(vao.value)
 }
 
 func (glx *Context) UnbindVertexArray() {
-	glx.constants.BindVertexArray(glx.factory.Null())
+	glx.bindVertexArray(glx.factory.Null())
 }
 
 //go:generate stringer -type=PrimitiveDrawMode
@@ -324,6 +473,7 @@ func (glx *Context) CreateFramebuffer() FramebufferObject {
 func (fbo FramebufferObject) Destroy() {
 	glx := fbo.glx
 	glx.constants.DeleteFramebuffer(fbo.value)
+	glx.state.forget(fbo.value)
 }
 
 type TextureObject struct {
@@ -1,7 +1,9 @@
 package gl
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/PieterD/warp/pkg/driver"
 	"github.com/go-gl/mathgl/mgl32"
@@ -142,9 +144,268 @@ func (u Uniform) Sampler(textureIndex int) {
 	u.Int(textureIndex)
 }
 
+func (u Uniform) IVec2(x, y int) {
+	glx := u.glx
+	glx.constants.Uniform2i(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+	)
+}
+
+func (u Uniform) IVec3(x, y, z int) {
+	glx := u.glx
+	glx.constants.Uniform3i(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+		glx.factory.Number(float64(z)),
+	)
+}
+
+func (u Uniform) IVec4(x, y, z, w int) {
+	glx := u.glx
+	glx.constants.Uniform4i(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+		glx.factory.Number(float64(z)),
+		glx.factory.Number(float64(w)),
+	)
+}
+
+func (u Uniform) Uint(v uint32) {
+	glx := u.glx
+	glx.constants.Uniform1ui(u.value, glx.factory.Number(float64(v)))
+}
+
+func (u Uniform) UVec2(x, y uint32) {
+	glx := u.glx
+	glx.constants.Uniform2ui(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+	)
+}
+
+func (u Uniform) UVec3(x, y, z uint32) {
+	glx := u.glx
+	glx.constants.Uniform3ui(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+		glx.factory.Number(float64(z)),
+	)
+}
+
+func (u Uniform) UVec4(x, y, z, w uint32) {
+	glx := u.glx
+	glx.constants.Uniform4ui(
+		u.value,
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+		glx.factory.Number(float64(z)),
+		glx.factory.Number(float64(w)),
+	)
+}
+
+// Bool sets a bool uniform, which is set like an int.
+func (u Uniform) Bool(v bool) {
+	i := 0
+	if v {
+		i = 1
+	}
+	u.Int(i)
+}
+
+// Mat2 sets a mat2 uniform. Matrices are column major, like mgl32's.
+// If transpose is true, they are transposed first; WebGL1 does not support this.
+func (u Uniform) Mat2(v mgl32.Mat2, transpose bool) {
+	u.Mat2Array([]mgl32.Mat2{v}, transpose)
+}
+
+func (u Uniform) Mat3(v mgl32.Mat3, transpose bool) {
+	u.Mat3Array([]mgl32.Mat3{v}, transpose)
+}
+
+func (u Uniform) Mat4(v mgl32.Mat4, transpose bool) {
+	u.Mat4Array([]mgl32.Mat4{v}, transpose)
+}
+
+// FloatArray sets the elements of a float array uniform, starting at the uniform's location.
+// The other array setters do the same for their types.
+func (u Uniform) FloatArray(v []float32) {
+	u.array(u.glx.constants.Uniform1fv, driver.Float32Array, v)
+}
+
+func (u Uniform) Vec2Array(v []mgl32.Vec2) {
+	u.array(u.glx.constants.Uniform2fv, driver.Float32Array, v)
+}
+
+func (u Uniform) Vec3Array(v []mgl32.Vec3) {
+	u.array(u.glx.constants.Uniform3fv, driver.Float32Array, v)
+}
+
+func (u Uniform) Vec4Array(v []mgl32.Vec4) {
+	u.array(u.glx.constants.Uniform4fv, driver.Float32Array, v)
+}
+
+func (u Uniform) IntArray(v []int32) {
+	u.array(u.glx.constants.Uniform1iv, driver.Int32Array, v)
+}
+
+func (u Uniform) IVec2Array(v [][2]int32) {
+	u.array(u.glx.constants.Uniform2iv, driver.Int32Array, v)
+}
+
+func (u Uniform) IVec3Array(v [][3]int32) {
+	u.array(u.glx.constants.Uniform3iv, driver.Int32Array, v)
+}
+
+func (u Uniform) IVec4Array(v [][4]int32) {
+	u.array(u.glx.constants.Uniform4iv, driver.Int32Array, v)
+}
+
+func (u Uniform) UintArray(v []uint32) {
+	u.array(u.glx.constants.Uniform1uiv, driver.Uint32Array, v)
+}
+
+func (u Uniform) UVec2Array(v [][2]uint32) {
+	u.array(u.glx.constants.Uniform2uiv, driver.Uint32Array, v)
+}
+
+func (u Uniform) UVec3Array(v [][3]uint32) {
+	u.array(u.glx.constants.Uniform3uiv, driver.Uint32Array, v)
+}
+
+func (u Uniform) UVec4Array(v [][4]uint32) {
+	u.array(u.glx.constants.Uniform4uiv, driver.Uint32Array, v)
+}
+
+// Mat2Array sets the elements of a mat2 array uniform, which are transposed first if transpose is true.
+func (u Uniform) Mat2Array(v []mgl32.Mat2, transpose bool) {
+	u.array(u.glx.constants.UniformMatrix2fv, driver.Float32Array, v, u.glx.factory.Boolean(transpose))
+}
+
+func (u Uniform) Mat3Array(v []mgl32.Mat3, transpose bool) {
+	u.array(u.glx.constants.UniformMatrix3fv, driver.Float32Array, v, u.glx.factory.Boolean(transpose))
+}
+
+func (u Uniform) Mat4Array(v []mgl32.Mat4, transpose bool) {
+	u.array(u.glx.constants.UniformMatrix4fv, driver.Float32Array, v, u.glx.factory.Boolean(transpose))
+}
+
+// array calls a vector uniform function with v, a slice of 4 byte elements of arrayType, or arrays of them.
+// The matrix functions take a transpose flag before the data.
+func (u Uniform) array(function func(args ...driver.Value) driver.Value, arrayType driver.ArrayType, v interface{}, transpose ...driver.Value) {
+	glx := u.glx
+	var data []byte
+	switch v := v.(type) {
+	case []float32:
+		data = putFloat32s(glx.staging.encode(len(v)*4), v)
+	case []mgl32.Vec2:
+		data = glx.staging.encode(len(v) * 2 * 4)
+		for i := range v {
+			putFloat32s(data[i*2*4:], v[i][:])
+		}
+	case []mgl32.Vec3:
+		data = glx.staging.encode(len(v) * 3 * 4)
+		for i := range v {
+			putFloat32s(data[i*3*4:], v[i][:])
+		}
+	case []mgl32.Vec4:
+		data = glx.staging.encode(len(v) * 4 * 4)
+		for i := range v {
+			putFloat32s(data[i*4*4:], v[i][:])
+		}
+	case []mgl32.Mat2:
+		data = glx.staging.encode(len(v) * 4 * 4)
+		for i := range v {
+			putFloat32s(data[i*4*4:], v[i][:])
+		}
+	case []mgl32.Mat3:
+		data = glx.staging.encode(len(v) * 9 * 4)
+		for i := range v {
+			putFloat32s(data[i*9*4:], v[i][:])
+		}
+	case []mgl32.Mat4:
+		data = glx.staging.encode(len(v) * 16 * 4)
+		for i := range v {
+			putFloat32s(data[i*16*4:], v[i][:])
+		}
+	case []int32:
+		data = putInt32s(glx.staging.encode(len(v)*4), v)
+	case [][2]int32:
+		data = glx.staging.encode(len(v) * 2 * 4)
+		for i := range v {
+			putInt32s(data[i*2*4:], v[i][:])
+		}
+	case [][3]int32:
+		data = glx.staging.encode(len(v) * 3 * 4)
+		for i := range v {
+			putInt32s(data[i*3*4:], v[i][:])
+		}
+	case [][4]int32:
+		data = glx.staging.encode(len(v) * 4 * 4)
+		for i := range v {
+			putInt32s(data[i*4*4:], v[i][:])
+		}
+	case []uint32:
+		data = putUint32s(glx.staging.encode(len(v)*4), v)
+	case [][2]uint32:
+		data = glx.staging.encode(len(v) * 2 * 4)
+		for i := range v {
+			putUint32s(data[i*2*4:], v[i][:])
+		}
+	case [][3]uint32:
+		data = glx.staging.encode(len(v) * 3 * 4)
+		for i := range v {
+			putUint32s(data[i*3*4:], v[i][:])
+		}
+	case [][4]uint32:
+		data = glx.staging.encode(len(v) * 4 * 4)
+		for i := range v {
+			putUint32s(data[i*4*4:], v[i][:])
+		}
+	default:
+		panic(fmt.Errorf("unsupported uniform array type: %T", v))
+	}
+	if len(data) == 0 {
+		return
+	}
+	jsBuffer, _ := glx.staging.get(len(data))
+	jsBuffer.Put(data)
+	// A view of the exact length, since WebGL1 has no srcLength argument.
+	jsArray := jsBuffer.View(arrayType, 0, len(data)/4)
+	args := append([]driver.Value{u.value}, transpose...)
+	function(append(args, jsArray)...)
+}
+
+func putFloat32s(data []byte, v []float32) []byte {
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(f))
+	}
+	return data
+}
+
+func putInt32s(data []byte, v []int32) []byte {
+	for i, n := range v {
+		binary.LittleEndian.PutUint32(data[i*4:], uint32(n))
+	}
+	return data
+}
+
+func putUint32s(data []byte, v []uint32) []byte {
+	for i, n := range v {
+		binary.LittleEndian.PutUint32(data[i*4:], n)
+	}
+	return data
+}
+
 type ShaderObject struct {
 	glx   *Context
 	value driver.Value
+	typ   ShaderType
 }
 
 //go:generate stringer -type=ShaderType
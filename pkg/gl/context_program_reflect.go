@@ -0,0 +1,250 @@
+package gl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// ActiveAttribute describes a vertex attribute used by a linked program.
+type ActiveAttribute struct {
+	Name string
+	// Type is zero if the attribute's type has no Type value.
+	Type Type
+	// Size is the number of elements if the attribute is an array, and 1 otherwise.
+	Size int
+	// Location is -1 for built-in attributes like gl_VertexID.
+	Location int
+}
+
+// ActiveUniform describes a uniform used by a linked program.
+type ActiveUniform struct {
+	// Name is the name of the uniform; for arrays it ends in [0].
+	Name string
+	// Type is zero if the uniform's type has no Type value.
+	Type Type
+	// Size is the number of elements if the uniform is an array, and 1 otherwise.
+	Size int
+	// Location can be used to set the uniform; it is nil if the uniform is part of a uniform block,
+	// or has no location.
+	Location *Uniform
+	// BlockIndex is the index of the uniform block the uniform is part of, or -1.
+	BlockIndex int
+	// Offset, ArrayStride and MatrixStride give the layout of the uniform in its block's buffer, in bytes.
+	// They are -1 if the uniform is not part of a block, or the stride does not apply.
+	Offset       int
+	ArrayStride  int
+	MatrixStride int
+	RowMajor     bool
+}
+
+// UniformBlock describes a uniform block used by a linked program.
+type UniformBlock struct {
+	Name  string
+	Index int
+	// Binding is the uniform buffer binding point set with UniformBlockBinding.
+	Binding int
+	// DataSize is the size of the buffer needed to back the block, in bytes.
+	DataSize int
+	// Uniforms are the members of the block, ordered by offset.
+	Uniforms []ActiveUniform
+}
+
+type activeInfo struct {
+	Name string       `js:"name"`
+	Size int          `js:"size"`
+	Type driver.Value `js:"type"`
+}
+
+// activeInfo returns the WebGLActiveInfo returned by get for the given index.
+// It returns false if there is none, which happens when the context is lost.
+func (program ProgramObject) activeInfo(get func(args ...driver.Value) driver.Value, index int) (info activeInfo, ok bool) {
+	glx := program.glx
+	value := get(program.value, glx.factory.Number(float64(index)))
+	if value.IsNull() || value.IsUndefined() {
+		return info, false
+	}
+	if err := driver.Unmarshal(value, &info); err != nil {
+		panic(fmt.Errorf("unmarshaling active info: %w", err))
+	}
+	return info, true
+}
+
+func (program ProgramObject) intParameter(param driver.Value, name string) int {
+	glx := program.glx
+	paramValue := glx.constants.GetProgramParameter(program.value, param)
+	if paramValue.IsNull() {
+		// The context is lost.
+		return 0
+	}
+	f, ok := paramValue.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("program parameter %s should return number: %T", name, paramValue))
+	}
+	return int(f)
+}
+
+// ActiveAttributes returns the attributes used by the linked program.
+func (program ProgramObject) ActiveAttributes() []ActiveAttribute {
+	glx := program.glx
+	var attributes []ActiveAttribute
+	num := program.intParameter(glx.constants.ACTIVE_ATTRIBUTES, "ACTIVE_ATTRIBUTES")
+	for i := 0; i < num; i++ {
+		info, ok := program.activeInfo(glx.constants.GetActiveAttrib, i)
+		if !ok {
+			continue
+		}
+		typ, _ := glx.typeConverter.FromJs(info.Type)
+		locationValue := glx.constants.GetAttribLocation(program.value, glx.factory.String(info.Name))
+		location, ok := locationValue.ToFloat64()
+		if !ok {
+			panic(fmt.Errorf("GetAttribLocation should return number: %T", locationValue))
+		}
+		attributes = append(attributes, ActiveAttribute{
+			Name:     info.Name,
+			Type:     typ,
+			Size:     info.Size,
+			Location: int(location),
+		})
+	}
+	return attributes
+}
+
+// ActiveUniforms returns the uniforms used by the linked program, including those in uniform blocks.
+func (program ProgramObject) ActiveUniforms() []ActiveUniform {
+	glx := program.glx
+	var uniforms []ActiveUniform
+	var indices []driver.Value
+	num := program.intParameter(glx.constants.ACTIVE_UNIFORMS, "ACTIVE_UNIFORMS")
+	for i := 0; i < num; i++ {
+		info, ok := program.activeInfo(glx.constants.GetActiveUniform, i)
+		if !ok {
+			continue
+		}
+		typ, _ := glx.typeConverter.FromJs(info.Type)
+		uniforms = append(uniforms, ActiveUniform{
+			Name:         info.Name,
+			Type:         typ,
+			Size:         info.Size,
+			BlockIndex:   -1,
+			Offset:       -1,
+			ArrayStride:  -1,
+			MatrixStride: -1,
+		})
+		indices = append(indices, glx.factory.Number(float64(i)))
+	}
+	if len(uniforms) > 0 && !glx.webgl1 {
+		// WebGL1 has no uniform blocks.
+		blockIndices := program.activeUniforms(indices, glx.constants.UNIFORM_BLOCK_INDEX, "UNIFORM_BLOCK_INDEX")
+		offsets := program.activeUniforms(indices, glx.constants.UNIFORM_OFFSET, "UNIFORM_OFFSET")
+		arrayStrides := program.activeUniforms(indices, glx.constants.UNIFORM_ARRAY_STRIDE, "UNIFORM_ARRAY_STRIDE")
+		matrixStrides := program.activeUniforms(indices, glx.constants.UNIFORM_MATRIX_STRIDE, "UNIFORM_MATRIX_STRIDE")
+		rowMajors := program.activeUniforms(indices, glx.constants.UNIFORM_IS_ROW_MAJOR, "UNIFORM_IS_ROW_MAJOR")
+		for i := range uniforms {
+			uniforms[i].BlockIndex = int(blockIndices[i])
+			uniforms[i].Offset = int(offsets[i])
+			uniforms[i].ArrayStride = int(arrayStrides[i])
+			uniforms[i].MatrixStride = int(matrixStrides[i])
+			uniforms[i].RowMajor = rowMajors[i] != 0
+		}
+	}
+	for i, uniform := range uniforms {
+		if uniform.BlockIndex != -1 {
+			continue
+		}
+		location := glx.constants.GetUniformLocation(program.value, glx.factory.String(uniform.Name))
+		if location.IsNull() {
+			continue
+		}
+		uniforms[i].Location = &Uniform{
+			glx:   glx,
+			value: location,
+		}
+	}
+	return uniforms
+}
+
+// activeUniforms returns a parameter of the uniforms at the given indices, with booleans as 0 or 1.
+// If the context is lost, all parameters are -1.
+func (program ProgramObject) activeUniforms(indices []driver.Value, param driver.Value, name string) []float64 {
+	glx := program.glx
+	values := make([]float64, len(indices))
+	rv, ok := glx.constants.GetActiveUniforms(program.value, glx.factory.Array(indices...), param).ToObject()
+	if !ok {
+		for i := range values {
+			values[i] = -1
+		}
+		return values
+	}
+	for i, value := range driver.IndexableToSlice(glx.factory, rv) {
+		if i >= len(values) {
+			break
+		}
+		if b, ok := value.ToBoolean(); ok {
+			if b {
+				values[i] = 1
+			}
+			continue
+		}
+		f, ok := value.ToFloat64()
+		if !ok {
+			panic(fmt.Errorf("active uniform parameter %s should return number: %T", name, value))
+		}
+		values[i] = f
+	}
+	return values
+}
+
+// UniformBlocks returns the uniform blocks used by the linked program.
+// It returns nil in WebGL1, which has no uniform blocks.
+func (program ProgramObject) UniformBlocks() []UniformBlock {
+	glx := program.glx
+	if glx.webgl1 {
+		return nil
+	}
+	var blocks []UniformBlock
+	num := program.intParameter(glx.constants.ACTIVE_UNIFORM_BLOCKS, "ACTIVE_UNIFORM_BLOCKS")
+	if num == 0 {
+		return nil
+	}
+	uniforms := program.ActiveUniforms()
+	for i := 0; i < num; i++ {
+		index := glx.factory.Number(float64(i))
+		name, ok := glx.constants.GetActiveUniformBlockName(program.value, index).ToString()
+		if !ok {
+			// The context is lost.
+			continue
+		}
+		block := UniformBlock{
+			Name:     name,
+			Index:    i,
+			Binding:  program.uniformBlockParameter(index, glx.constants.UNIFORM_BLOCK_BINDING, "UNIFORM_BLOCK_BINDING"),
+			DataSize: program.uniformBlockParameter(index, glx.constants.UNIFORM_BLOCK_DATA_SIZE, "UNIFORM_BLOCK_DATA_SIZE"),
+		}
+		for _, uniform := range uniforms {
+			if uniform.BlockIndex == i {
+				block.Uniforms = append(block.Uniforms, uniform)
+			}
+		}
+		sort.SliceStable(block.Uniforms, func(a, b int) bool {
+			return block.Uniforms[a].Offset < block.Uniforms[b].Offset
+		})
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func (program ProgramObject) uniformBlockParameter(index driver.Value, param driver.Value, name string) int {
+	glx := program.glx
+	paramValue := glx.constants.GetActiveUniformBlockParameter(program.value, index, param)
+	if paramValue.IsNull() {
+		// The context is lost.
+		return 0
+	}
+	f, ok := paramValue.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("uniform block parameter %s should return number: %T", name, paramValue))
+	}
+	return int(f)
+}
@@ -0,0 +1,125 @@
+package gl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestProgramReflection(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		activeInfo := func(name string, size int, typ string) driver.Value {
+			info := factory.Object()
+			info.Set("name", factory.String(name))
+			info.Set("size", factory.Number(float64(size)))
+			info.Set("type", glObj.Get(typ))
+			return info
+		}
+		numbers := func(values ...float64) driver.Value {
+			var array []driver.Value
+			for _, v := range values {
+				array = append(array, factory.Number(v))
+			}
+			return factory.Array(array...)
+		}
+		params := map[string]driver.Value{
+			"ACTIVE_ATTRIBUTES":     factory.Number(2),
+			"ACTIVE_UNIFORMS":       factory.Number(3),
+			"ACTIVE_UNIFORM_BLOCKS": factory.Number(1),
+		}
+		uniformParams := map[string]driver.Value{
+			"UNIFORM_BLOCK_INDEX":   numbers(-1, 0, 0),
+			"UNIFORM_OFFSET":        numbers(-1, 64, 0),
+			"UNIFORM_ARRAY_STRIDE":  numbers(-1, 0, 0),
+			"UNIFORM_MATRIX_STRIDE": numbers(-1, 0, 16),
+			"UNIFORM_IS_ROW_MAJOR":  factory.Array(factory.Boolean(false), factory.Boolean(false), factory.Boolean(false)),
+		}
+		param := func(value driver.Value, params map[string]driver.Value) driver.Value {
+			for name, result := range params {
+				if factory.Equal(value, glObj.Get(name)) {
+					return result
+				}
+			}
+			return factory.Undefined()
+		}
+		glObj.SetFunc("getProgramParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			return param(args[1], params)
+		})
+		glObj.SetFunc("getActiveAttrib", func(this driver.Object, args ...driver.Value) driver.Value {
+			if i, _ := args[1].ToFloat64(); i == 0 {
+				return activeInfo("Position", 1, "FLOAT_VEC3")
+			}
+			return activeInfo("Weights", 4, "FLOAT")
+		})
+		glObj.SetFunc("getAttribLocation", func(this driver.Object, args ...driver.Value) driver.Value {
+			if name, _ := args[1].ToString(); name == "Position" {
+				return factory.Number(0)
+			}
+			return factory.Number(1)
+		})
+		glObj.SetFunc("getActiveUniform", func(this driver.Object, args ...driver.Value) driver.Value {
+			switch i, _ := args[1].ToFloat64(); i {
+			case 0:
+				return activeInfo("Texture", 1, "SAMPLER_2D")
+			case 1:
+				return activeInfo("Camera.Position", 1, "FLOAT_VEC3")
+			default:
+				return activeInfo("Camera.View", 1, "FLOAT_MAT4")
+			}
+		})
+		glObj.SetFunc("getActiveUniforms", func(this driver.Object, args ...driver.Value) driver.Value {
+			return param(args[2], uniformParams)
+		})
+		glObj.SetFunc("getUniformLocation", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Object()
+		})
+		glObj.SetFunc("getActiveUniformBlockName", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.String("Camera")
+		})
+		glObj.SetFunc("getActiveUniformBlockParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if factory.Equal(args[2], glObj.Get("UNIFORM_BLOCK_DATA_SIZE")) {
+				return factory.Number(80)
+			}
+			return factory.Number(2)
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	program := glx.CreateProgram()
+
+	wantAttributes := []ActiveAttribute{
+		{Name: "Position", Type: Vec3, Size: 1, Location: 0},
+		{Name: "Weights", Type: Float, Size: 4, Location: 1},
+	}
+	if got := program.ActiveAttributes(); !reflect.DeepEqual(got, wantAttributes) {
+		t.Fatalf("expected attributes %+v, got: %+v", wantAttributes, got)
+	}
+
+	uniforms := program.ActiveUniforms()
+	if len(uniforms) != 3 {
+		t.Fatalf("expected 3 uniforms, got: %+v", uniforms)
+	}
+	if texture := uniforms[0]; texture.Type != Sampler2D || texture.BlockIndex != -1 || texture.Location == nil {
+		t.Fatalf("expected Texture to be a sampler outside of a block, got: %+v", texture)
+	}
+	if view := uniforms[2]; view.Type != Mat4 || view.BlockIndex != 0 || view.MatrixStride != 16 || view.Location != nil {
+		t.Fatalf("expected Camera.View to be a matrix in block 0, got: %+v", view)
+	}
+
+	blocks := program.UniformBlocks()
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 uniform block, got: %+v", blocks)
+	}
+	block := blocks[0]
+	if block.Name != "Camera" || block.Binding != 2 || block.DataSize != 80 {
+		t.Fatalf("unexpected uniform block: %+v", block)
+	}
+	var names []string
+	for _, uniform := range block.Uniforms {
+		names = append(names, uniform.Name)
+	}
+	if want := []string{"Camera.View", "Camera.Position"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected block members %v ordered by offset, got: %v", want, names)
+	}
+}
@@ -0,0 +1,84 @@
+package gl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestUniformSetters(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	program := glx.CreateProgram()
+	uniform, err := program.Uniform("Value")
+	if err != nil {
+		t.Fatalf("getting uniform: %v", err)
+	}
+
+	uniform.IVec3(1, -2, 3)
+	calls := canvas.factory.CallsTo("uniform3i")
+	if len(calls) != 1 || len(calls[0].Args) != 4 {
+		t.Fatalf("expected 1 uniform3i call with 4 arguments, got: %v", calls)
+	}
+	if y, _ := calls[0].Args[2].ToFloat64(); y != -2 {
+		t.Fatalf("expected y to be -2, got: %v", y)
+	}
+
+	uniform.Bool(true)
+	calls = canvas.factory.CallsTo("uniform1i")
+	if len(calls) != 1 {
+		t.Fatalf("expected Bool to call uniform1i, got %d calls", len(calls))
+	}
+	if v, _ := calls[0].Args[1].ToFloat64(); v != 1 {
+		t.Fatalf("expected true to be 1, got: %v", v)
+	}
+
+	view := mgl32.LookAtV(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	uniform.Mat4(view, true)
+	calls = canvas.factory.CallsTo("uniformMatrix4fv")
+	if len(calls) != 1 || len(calls[0].Args) != 3 {
+		t.Fatalf("expected 1 uniformMatrix4fv call with 3 arguments, got: %v", calls)
+	}
+	if transpose, _ := calls[0].Args[1].ToBoolean(); !transpose {
+		t.Fatalf("expected transpose to be passed")
+	}
+	if got, want := uniformData(t, calls[0].Args[2]), view[:]; !bytes.Equal(got, encode(t, want)) {
+		t.Fatalf("expected matrix data %v, got: %v", encode(t, want), got)
+	}
+
+	uniform.UVec2Array([][2]uint32{{1, 2}, {3, 4}, {5, 6}})
+	calls = canvas.factory.CallsTo("uniform2uiv")
+	if len(calls) != 1 || len(calls[0].Args) != 2 {
+		t.Fatalf("expected 1 uniform2uiv call with 2 arguments, got: %v", calls)
+	}
+	if got, want := uniformData(t, calls[0].Args[1]), []uint32{1, 2, 3, 4, 5, 6}; !bytes.Equal(got, encode(t, want)) {
+		t.Fatalf("expected array data %v, got: %v", encode(t, want), got)
+	}
+
+	uniform.FloatArray(nil)
+	if calls := canvas.factory.CallsTo("uniform1fv"); len(calls) != 0 {
+		t.Fatalf("expected an empty array not to be set, got %d calls", len(calls))
+	}
+}
+
+func uniformData(t *testing.T, value driver.Value) []byte {
+	t.Helper()
+	data, ok := fakejs.Bytes(value)
+	if !ok {
+		t.Fatalf("expected a typed array, got: %v", value)
+	}
+	return data
+}
+
+func encode(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+		t.Fatalf("encoding %T: %v", v, err)
+	}
+	return buf.Bytes()
+}
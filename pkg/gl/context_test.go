@@ -0,0 +1,199 @@
+package gl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+type fakeCanvas struct {
+	factory *fakejs.Factory
+	obj     *fakejs.Object
+}
+
+func (c fakeCanvas) Driver() (factory driver.Factory, obj driver.Object) {
+	return c.factory, c.obj
+}
+
+// newFakeCanvas creates a canvas whose getContext returns a fake WebGL2 context.
+// Every constant in glConstants gets a unique number, and every function is a stub.
+// Create* functions return a fresh object, all others return undefined.
+// The script function may replace stubs before the context is created.
+func newFakeCanvas(script func(factory *fakejs.Factory, glObj *fakejs.Object)) fakeCanvas {
+	factory := fakejs.Open()
+	glObj := factory.Object()
+	t := reflect.TypeOf(glConstants{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.Func {
+			glObj.Set(field.Name, factory.Number(float64(0x1000+i)))
+			continue
+		}
+		functionName := strings.ToLower(field.Name[:1]) + field.Name[1:]
+		isCreate := strings.HasPrefix(functionName, "create")
+		glObj.SetFunc(functionName, func(this driver.Object, args ...driver.Value) driver.Value {
+			if isCreate {
+				return factory.Object()
+			}
+			return factory.Undefined()
+		})
+	}
+	if script != nil {
+		script(factory, glObj)
+	}
+	canvasObj := factory.Object()
+	canvasObj.SetFunc("getContext", func(this driver.Object, args ...driver.Value) driver.Value {
+		if name, _ := args[0].ToString(); name != "webgl2" {
+			return factory.Null()
+		}
+		return glObj
+	})
+	return fakeCanvas{
+		factory: factory,
+		obj:     canvasObj,
+	}
+}
+
+func newTestContext(t testing.TB, canvas Canvas, cfg ContextConfig) *Context {
+	glx, err := NewContextConfig(canvas, cfg)
+	if err != nil {
+		t.Fatalf("creating context: %v", err)
+	}
+	return glx
+}
+
+func TestNewContext(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx, err := NewContext(canvas)
+	if err != nil {
+		t.Fatalf("creating context: %v", err)
+	}
+	buffer := glx.CreateBuffer()
+	glx.Targets().Array().BindBuffer(buffer)
+	glx.Targets().Array().BufferData([]byte{1, 2, 3}, Static, Draw)
+
+	calls := canvas.factory.CallsTo("bindBuffer")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 bindBuffer call, got: %d", len(calls))
+	}
+	if !canvas.factory.Equal(calls[0].Args[0], glx.constants.ARRAY_BUFFER) {
+		t.Fatalf("expected bindBuffer to ARRAY_BUFFER, got: %v", calls[0].Args[0])
+	}
+	if !canvas.factory.Equal(calls[0].Args[1], buffer.value) {
+		t.Fatalf("expected bindBuffer to bind the created buffer, got: %v", calls[0].Args[1])
+	}
+	calls = canvas.factory.CallsTo("bufferData")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 bufferData call, got: %d", len(calls))
+	}
+	data, ok := fakejs.Bytes(calls[0].Args[1])
+	if !ok {
+		t.Fatalf("expected bufferData to be passed a typed array, got: %v", calls[0].Args[1])
+	}
+	if length, _ := calls[0].Args[4].ToFloat64(); int(length) != 3 {
+		t.Fatalf("expected bufferData length 3, got: %v", calls[0].Args[4])
+	}
+	if want := []byte{1, 2, 3}; !reflect.DeepEqual(data[:3], want) {
+		t.Fatalf("expected uploaded data %v, got: %v", want, data[:3])
+	}
+}
+
+func TestNewContextUnsupported(t *testing.T) {
+	factory := fakejs.Open()
+	canvasObj := factory.Object()
+	canvasObj.SetFunc("getContext", func(this driver.Object, args ...driver.Value) driver.Value {
+		return factory.Null()
+	})
+	if _, err := NewContext(fakeCanvas{factory: factory, obj: canvasObj}); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestCommandBufferUnsupported(t *testing.T) {
+	// fakejs can not compile the replay function, so calls are made directly.
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{CommandBuffer: true})
+	glx.Viewport(0, 0, 640, 480)
+	if calls := canvas.factory.CallsTo("viewport"); len(calls) != 1 {
+		t.Fatalf("expected 1 viewport call, got: %d", len(calls))
+	}
+	if stats := glx.CommandBufferStats(); stats != (CommandBufferStats{}) {
+		t.Fatalf("expected no command buffer stats, got: %+v", stats)
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	attributes := DefaultContextAttributes()
+	attributes.Alpha = false
+	attributes.PowerPreference = PowerHighPerformance
+	newTestContext(t, canvas, ContextConfig{Attributes: &attributes})
+	calls := canvas.factory.CallsTo("getContext")
+	if len(calls) != 1 || len(calls[0].Args) != 2 {
+		t.Fatalf("expected getContext to be called once with attributes, got: %v", calls)
+	}
+	var got ContextAttributes
+	if err := driver.Unmarshal(calls[0].Args[1], &got); err != nil {
+		t.Fatalf("unmarshaling attributes: %v", err)
+	}
+	if got != attributes {
+		t.Fatalf("expected attributes %+v, got: %+v", attributes, got)
+	}
+}
+
+func TestWebGL1Fallback(t *testing.T) {
+	var glObj *fakejs.Object
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, obj *fakejs.Object) {
+		glObj = obj
+		for _, feature := range webgl1Features {
+			for _, functionName := range feature.functions {
+				obj.Delete(functionName)
+			}
+		}
+		obj.Delete("UNIFORM_BUFFER")
+		extension := factory.Object()
+		for _, functionName := range []string{"createVertexArrayOES", "deleteVertexArrayOES", "bindVertexArrayOES"} {
+			extension.SetFunc(functionName, func(this driver.Object, args ...driver.Value) driver.Value {
+				return factory.Object()
+			})
+		}
+		obj.SetFunc("getExtension", func(this driver.Object, args ...driver.Value) driver.Value {
+			if name, _ := args[0].ToString(); name == "OES_vertex_array_object" {
+				return extension
+			}
+			return factory.Null()
+		})
+	})
+	canvas.obj.SetFunc("getContext", func(this driver.Object, args ...driver.Value) driver.Value {
+		if name, _ := args[0].ToString(); name != "webgl" {
+			return canvas.factory.Null()
+		}
+		return glObj
+	})
+
+	if _, err := NewContext(canvas); err == nil {
+		t.Fatalf("expected an error without the fallback")
+	}
+	glx := newTestContext(t, canvas, ContextConfig{WebGL1Fallback: true})
+	if !glx.IsWebGL1() {
+		t.Fatalf("expected a WebGL1 context")
+	}
+	want := []string{"instanced drawing", "uniform buffers", "unsigned integer uniforms", "buffer readback", "sync objects", "transform feedback", "queries", "multisampled renderbuffers"}
+	if got := glx.Degraded(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected degraded features %v, got: %v", want, got)
+	}
+	glx.BindVertexArray(glx.CreateVertexArray())
+	if calls := canvas.factory.CallsTo("bindVertexArrayOES"); len(calls) != 1 {
+		t.Fatalf("expected bindVertexArrayOES to be called, got %d calls", len(calls))
+	}
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "queries are unavailable in WebGL1") {
+			t.Fatalf("expected a panic for a degraded feature, got: %v", r)
+		}
+	}()
+	glx.CreateQuery()
+}
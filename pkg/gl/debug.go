@@ -0,0 +1,134 @@
+package gl
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// maxErrorsPerCall limits how many errors are read from getError after a single call.
+const maxErrorsPerCall = 8
+
+var glErrorNames = []string{
+	"INVALID_ENUM",
+	"INVALID_VALUE",
+	"INVALID_OPERATION",
+	"INVALID_FRAMEBUFFER_OPERATION",
+	"OUT_OF_MEMORY",
+	"CONTEXT_LOST_WEBGL",
+}
+
+// GLError is an error reported by getError after a GL call.
+type GLError struct {
+	// Code is the value returned by getError, and Name its constant name, like INVALID_ENUM.
+	Code int
+	Name string
+	// Function and Args describe the GL call after which the error was reported.
+	Function string
+	Args     []driver.Value
+	// Caller is the file and line of the call into this package that made the GL call.
+	Caller string
+}
+
+func (e *GLError) Error() string {
+	var args []string
+	for _, arg := range e.Args {
+		args = append(args, formatValue(arg))
+	}
+	return fmt.Sprintf("%s after %s(%s) called from %s", e.Name, e.Function, strings.Join(args, ", "), e.Caller)
+}
+
+func formatValue(v driver.Value) string {
+	if v == nil || v.IsNull() {
+		return "null"
+	}
+	if v.IsUndefined() {
+		return "undefined"
+	}
+	if b, ok := v.ToBoolean(); ok {
+		return strconv.FormatBool(b)
+	}
+	if f, ok := v.ToFloat64(); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if s, ok := v.ToString(); ok {
+		return strconv.Quote(s)
+	}
+	return v.TypeOf()
+}
+
+// debugger checks getError after every GL call, and reports errors to a handler.
+type debugger struct {
+	getError func(args ...driver.Value) driver.Value
+	noError  float64
+	names    map[int]string
+	handler  func(err *GLError)
+}
+
+// newDebugger creates a debugger; getError must flush the command buffer, if there is one.
+func newDebugger(obj driver.Object, getError func(args ...driver.Value) driver.Value, handler func(err *GLError)) *debugger {
+	names := make(map[int]string)
+	for _, name := range glErrorNames {
+		if code, ok := obj.Get(name).ToFloat64(); ok {
+			names[int(code)] = name
+		}
+	}
+	noError, _ := obj.Get("NO_ERROR").ToFloat64()
+	return &debugger{
+		getError: getError,
+		noError:  noError,
+		names:    names,
+		handler:  handler,
+	}
+}
+
+func (debug *debugger) wrap(functionName string, f func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
+	return func(args ...driver.Value) driver.Value {
+		rv := f(args...)
+		debug.check(functionName, args)
+		return rv
+	}
+}
+
+func (debug *debugger) check(functionName string, args []driver.Value) {
+	for i := 0; i < maxErrorsPerCall; i++ {
+		code, ok := debug.getError().ToFloat64()
+		if !ok || code == debug.noError {
+			return
+		}
+		name, ok := debug.names[int(code)]
+		if !ok {
+			name = fmt.Sprintf("error 0x%x", int(code))
+		}
+		debug.handler(&GLError{
+			Code:     int(code),
+			Name:     name,
+			Function: functionName,
+			Args:     args,
+			Caller:   caller(),
+		})
+	}
+}
+
+var packagePrefix = reflect.TypeOf(Context{}).PkgPath() + "."
+
+// caller returns the location of the innermost call into this package.
+// Calls from tests in this package count as calls from outside.
+func caller() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package gl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestDebug(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		pending := glObj.Get("NO_ERROR")
+		glObj.SetFunc("bindBuffer", func(this driver.Object, args ...driver.Value) driver.Value {
+			pending = glObj.Get("INVALID_ENUM")
+			return factory.Undefined()
+		})
+		glObj.SetFunc("getError", func(this driver.Object, args ...driver.Value) driver.Value {
+			rv := pending
+			pending = glObj.Get("NO_ERROR")
+			return rv
+		})
+	})
+	var reported []*GLError
+	glx := newTestContext(t, canvas, ContextConfig{Debug: func(err *GLError) {
+		reported = append(reported, err)
+	}})
+	buffer := glx.CreateBuffer()
+	glx.Targets().Array().BindBuffer(buffer)
+	glx.Viewport(0, 0, 640, 480)
+
+	if len(reported) != 1 {
+		t.Fatalf("expected 1 reported error, got: %d", len(reported))
+	}
+	err := reported[0]
+	if err.Name != "INVALID_ENUM" || err.Function != "bindBuffer" || len(err.Args) != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "INVALID_ENUM after bindBuffer(") || !strings.Contains(err.Error(), "object) called from ") {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+	if !strings.Contains(err.Caller, "debug_test.go:") {
+		t.Fatalf("expected the caller to be in the test, got: %s", err.Caller)
+	}
+	if calls := canvas.factory.CallsTo("getError"); len(calls) != 4 {
+		t.Fatalf("expected getError after every call, got %d calls", len(calls))
+	}
+}
+
+func TestDebugMissingGetError(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.Delete("getError")
+	})
+	_, err := NewContextConfig(canvas, ContextConfig{Debug: func(err *GLError) {}})
+	if err == nil {
+		t.Fatalf("expected an error without getError")
+	}
+}
@@ -0,0 +1,233 @@
+package gl
+
+import (
+	"fmt"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// Extensions returns the names of the extensions supported by the context.
+// It returns nil if the context is lost.
+func (glx *Context) Extensions() []string {
+	list, ok := glx.constants.GetSupportedExtensions().ToObject()
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, value := range driver.IndexableToSlice(glx.factory, list) {
+		name, ok := value.ToString()
+		if !ok {
+			panic(fmt.Errorf("extension name is not a string: %v", value))
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// EnableExtension enables an extension by name, and returns false if it is not supported.
+// Extensions are disabled when the context is lost, and must be enabled again once it is restored.
+func (glx *Context) EnableExtension(name string) bool {
+	_, ok := glx.extension(name)
+	return ok
+}
+
+// extension enables an extension, and returns its object.
+func (glx *Context) extension(name string) (driver.Object, bool) {
+	return glx.constants.GetExtension(glx.factory.String(name)).ToObject()
+}
+
+// bindExtension enables an extension, and loads its constants and functions into the struct pointed to by ptr.
+// Its functions are wrapped like those of the context, except for command buffer recording.
+func (glx *Context) bindExtension(name string, ptr interface{}) bool {
+	obj, ok := glx.extension(name)
+	if !ok {
+		return false
+	}
+	binding := glx.binding
+	binding.webgl1 = nil
+	binding.bind(obj, ptr)
+	return true
+}
+
+// ColorBufferFloat enables EXT_color_buffer_float, which makes floating point formats color renderable.
+func (glx *Context) ColorBufferFloat() bool {
+	return glx.EnableExtension("EXT_color_buffer_float")
+}
+
+// TextureFloatLinear enables OES_texture_float_linear, which allows linear filtering of floating point textures.
+func (glx *Context) TextureFloatLinear() bool {
+	return glx.EnableExtension("OES_texture_float_linear")
+}
+
+type disjointTimerQueryConstants struct {
+	QUERY_COUNTER_BITS_EXT driver.Value
+	TIME_ELAPSED_EXT       driver.Value
+	TIMESTAMP_EXT          driver.Value
+	GPU_DISJOINT_EXT       driver.Value
+	QueryCounterEXT        func(args ...driver.Value) driver.Value
+}
+
+// DisjointTimerQuery wraps EXT_disjoint_timer_query_webgl2, which measures time spent on the GPU.
+type DisjointTimerQuery struct {
+	glx       *Context
+	constants disjointTimerQueryConstants
+}
+
+func (glx *Context) DisjointTimerQuery() (ext DisjointTimerQuery, ok bool) {
+	ext.glx = glx
+	return ext, glx.bindExtension("EXT_disjoint_timer_query_webgl2", &ext.constants)
+}
+
+// QueryCounter makes the query record the GPU time once all previous commands have completed.
+// The result is in nanoseconds.
+func (ext DisjointTimerQuery) QueryCounter(query QueryObject) {
+	ext.constants.QueryCounterEXT(query.value, ext.constants.TIMESTAMP_EXT)
+}
+
+// Disjoint returns true if something happened since the last call that makes timer query results unreliable,
+// such as a power saving change. It also resets the flag.
+func (ext DisjointTimerQuery) Disjoint() bool {
+	glx := ext.glx
+	paramValue := glx.constants.GetParameter(ext.constants.GPU_DISJOINT_EXT)
+	disjoint, ok := paramValue.ToBoolean()
+	if !ok {
+		panic(fmt.Errorf("parameter GPU_DISJOINT_EXT should return boolean: %T", paramValue))
+	}
+	return disjoint
+}
+
+// TimestampBits returns the number of bits used for timestamps; zero if QueryCounter is not supported.
+func (ext DisjointTimerQuery) TimestampBits() int {
+	glx := ext.glx
+	paramValue := glx.constants.GetQuery(ext.constants.TIMESTAMP_EXT, ext.constants.QUERY_COUNTER_BITS_EXT)
+	f, ok := paramValue.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("query QUERY_COUNTER_BITS_EXT should return number: %T", paramValue))
+	}
+	return int(f)
+}
+
+type debugRendererInfoConstants struct {
+	UNMASKED_VENDOR_WEBGL   driver.Value
+	UNMASKED_RENDERER_WEBGL driver.Value
+}
+
+// DebugRendererInfo wraps WEBGL_debug_renderer_info, which identifies the graphics driver.
+type DebugRendererInfo struct {
+	glx       *Context
+	constants debugRendererInfoConstants
+}
+
+func (glx *Context) DebugRendererInfo() (ext DebugRendererInfo, ok bool) {
+	ext.glx = glx
+	return ext, glx.bindExtension("WEBGL_debug_renderer_info", &ext.constants)
+}
+
+func (ext DebugRendererInfo) Vendor() string {
+	return ext.parameter(ext.constants.UNMASKED_VENDOR_WEBGL, "UNMASKED_VENDOR_WEBGL")
+}
+
+func (ext DebugRendererInfo) Renderer() string {
+	return ext.parameter(ext.constants.UNMASKED_RENDERER_WEBGL, "UNMASKED_RENDERER_WEBGL")
+}
+
+func (ext DebugRendererInfo) parameter(param driver.Value, name string) string {
+	glx := ext.glx
+	paramValue := glx.constants.GetParameter(param)
+	s, ok := paramValue.ToString()
+	if !ok {
+		panic(fmt.Errorf("parameter %s should return string: %T", name, paramValue))
+	}
+	return s
+}
+
+type textureFilterAnisotropicConstants struct {
+	TEXTURE_MAX_ANISOTROPY_EXT     driver.Value
+	MAX_TEXTURE_MAX_ANISOTROPY_EXT driver.Value
+}
+
+// TextureFilterAnisotropic wraps EXT_texture_filter_anisotropic, which improves the filtering of textures
+// seen at an angle.
+type TextureFilterAnisotropic struct {
+	glx       *Context
+	constants textureFilterAnisotropicConstants
+}
+
+func (glx *Context) TextureFilterAnisotropic() (ext TextureFilterAnisotropic, ok bool) {
+	ext.glx = glx
+	return ext, glx.bindExtension("EXT_texture_filter_anisotropic", &ext.constants)
+}
+
+// MaxAnisotropy returns the largest supported anisotropy.
+func (ext TextureFilterAnisotropic) MaxAnisotropy() float64 {
+	glx := ext.glx
+	paramValue := glx.constants.GetParameter(ext.constants.MAX_TEXTURE_MAX_ANISOTROPY_EXT)
+	f, ok := paramValue.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("parameter MAX_TEXTURE_MAX_ANISOTROPY_EXT should return number: %T", paramValue))
+	}
+	return f
+}
+
+// SetAnisotropy sets the anisotropy of the texture bound to the 2D texture target; 1 disables anisotropic filtering.
+func (ext TextureFilterAnisotropic) SetAnisotropy(anisotropy float64) {
+	glx := ext.glx
+	glx.constants.TexParameterf(
+		glx.constants.TEXTURE_2D,
+		ext.constants.TEXTURE_MAX_ANISOTROPY_EXT,
+		glx.factory.Number(anisotropy),
+	)
+}
+
+type multiviewConstants struct {
+	FRAMEBUFFER_ATTACHMENT_TEXTURE_NUM_VIEWS_OVR       driver.Value
+	FRAMEBUFFER_ATTACHMENT_TEXTURE_BASE_VIEW_INDEX_OVR driver.Value
+	MAX_VIEWS_OVR                                      driver.Value
+	FRAMEBUFFER_INCOMPLETE_VIEW_TARGETS_OVR            driver.Value
+	FramebufferTextureMultiviewOVR                     func(args ...driver.Value) driver.Value
+}
+
+// Multiview wraps OVR_multiview2, which renders to several layers of a texture array in a single draw call.
+type Multiview struct {
+	glx       *Context
+	constants multiviewConstants
+}
+
+func (glx *Context) Multiview() (ext Multiview, ok bool) {
+	ext.glx = glx
+	return ext, glx.bindExtension("OVR_multiview2", &ext.constants)
+}
+
+// MaxViews returns the largest supported number of views.
+func (ext Multiview) MaxViews() int {
+	glx := ext.glx
+	paramValue := glx.constants.GetParameter(ext.constants.MAX_VIEWS_OVR)
+	f, ok := paramValue.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("parameter MAX_VIEWS_OVR should return number: %T", paramValue))
+	}
+	return int(f)
+}
+
+// AttachTexture attaches numViews layers of a texture array, starting at baseViewIndex,
+// to the bound framebuffer.
+func (ext Multiview) AttachTexture(attachmentType RenderbufferType, texture TextureObject, level, baseViewIndex, numViews int) {
+	glx := ext.glx
+	var glType driver.Value
+	switch attachmentType {
+	case ColorBuffer:
+		glType = glx.constants.COLOR_ATTACHMENT0
+	case DepthStencilBuffer:
+		glType = glx.constants.DEPTH_STENCIL_ATTACHMENT
+	default:
+		panic(fmt.Errorf("invalid attachment type: %v", attachmentType))
+	}
+	ext.constants.FramebufferTextureMultiviewOVR(
+		glx.constants.FRAMEBUFFER,
+		glType,
+		texture.value,
+		glx.factory.Number(float64(level)),
+		glx.factory.Number(float64(baseViewIndex)),
+		glx.factory.Number(float64(numViews)),
+	)
+}
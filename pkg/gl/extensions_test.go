@@ -0,0 +1,86 @@
+package gl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestExtensions(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		debugInfo := factory.Object()
+		debugInfo.Set("UNMASKED_VENDOR_WEBGL", factory.Number(0x9245))
+		debugInfo.Set("UNMASKED_RENDERER_WEBGL", factory.Number(0x9246))
+		timerQuery := factory.Object()
+		for i, name := range []string{"QUERY_COUNTER_BITS_EXT", "TIME_ELAPSED_EXT", "TIMESTAMP_EXT", "GPU_DISJOINT_EXT"} {
+			timerQuery.Set(name, factory.Number(float64(0x8864+i)))
+		}
+		timerQuery.SetFunc("queryCounterEXT", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Undefined()
+		})
+		extensions := map[string]*fakejs.Object{
+			"WEBGL_debug_renderer_info":       debugInfo,
+			"EXT_disjoint_timer_query_webgl2": timerQuery,
+			"EXT_color_buffer_float":          factory.Object(),
+		}
+		glObj.SetFunc("getSupportedExtensions", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Array(
+				factory.String("EXT_color_buffer_float"),
+				factory.String("EXT_disjoint_timer_query_webgl2"),
+				factory.String("WEBGL_debug_renderer_info"),
+			)
+		})
+		glObj.SetFunc("getExtension", func(this driver.Object, args ...driver.Value) driver.Value {
+			name, _ := args[0].ToString()
+			if extension, ok := extensions[name]; ok {
+				return extension
+			}
+			return factory.Null()
+		})
+		glObj.SetFunc("getParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if param, _ := args[0].ToFloat64(); param == 0x9245 {
+				return factory.String("Warp Graphics")
+			}
+			return factory.Undefined()
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+
+	want := []string{"EXT_color_buffer_float", "EXT_disjoint_timer_query_webgl2", "WEBGL_debug_renderer_info"}
+	if got := glx.Extensions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected extensions %v, got: %v", want, got)
+	}
+	if !glx.ColorBufferFloat() {
+		t.Fatalf("expected EXT_color_buffer_float to be supported")
+	}
+	if glx.TextureFloatLinear() {
+		t.Fatalf("expected OES_texture_float_linear not to be supported")
+	}
+	if _, ok := glx.Multiview(); ok {
+		t.Fatalf("expected OVR_multiview2 not to be supported")
+	}
+
+	debugInfo, ok := glx.DebugRendererInfo()
+	if !ok {
+		t.Fatalf("expected WEBGL_debug_renderer_info to be supported")
+	}
+	if vendor := debugInfo.Vendor(); vendor != "Warp Graphics" {
+		t.Fatalf("expected vendor Warp Graphics, got: %s", vendor)
+	}
+
+	timerQuery, ok := glx.DisjointTimerQuery()
+	if !ok {
+		t.Fatalf("expected EXT_disjoint_timer_query_webgl2 to be supported")
+	}
+	query := glx.CreateQuery()
+	timerQuery.QueryCounter(query)
+	calls := canvas.factory.CallsTo("queryCounterEXT")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 queryCounterEXT call, got: %d", len(calls))
+	}
+	if !canvas.factory.Equal(calls[0].Args[0], query.value) || !canvas.factory.Equal(calls[0].Args[1], timerQuery.constants.TIMESTAMP_EXT) {
+		t.Fatalf("unexpected queryCounterEXT arguments: %v", calls[0].Args)
+	}
+}
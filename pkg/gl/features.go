@@ -12,11 +12,7 @@ type Features struct {
 
 func (fs Features) Blend(enable bool) {
 	glx := fs.glx
-	if enable {
-		glx.constants.Enable(glx.constants.BLEND)
-	} else {
-		glx.constants.Disable(glx.constants.BLEND)
-	}
+	glx.setEnabled(glx.constants.BLEND, enable)
 }
 
 type BlendFactor int
@@ -125,19 +121,13 @@ func (cf CullFace) glValue(glx *Context) driver.Value {
 
 func (fs Features) CullFace(enable bool, cullWhich CullFace) {
 	glx := fs.glx
+	glx.setEnabled(glx.constants.CULL_FACE, enable)
 	if enable {
-		glx.constants.Enable(glx.constants.CULL_FACE)
 		glx.constants.CullFace(cullWhich.glValue(glx))
-	} else {
-		glx.constants.Disable(glx.constants.CULL_FACE)
 	}
 }
 
 func (fs Features) Rasterizer(enable bool) {
 	glx := fs.glx
-	if enable {
-		glx.constants.Disable(glx.constants.RASTERIZER_DISCARD)
-	} else {
-		glx.constants.Enable(glx.constants.RASTERIZER_DISCARD)
-	}
+	glx.setEnabled(glx.constants.RASTERIZER_DISCARD, !enable)
 }
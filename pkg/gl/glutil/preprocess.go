@@ -0,0 +1,171 @@
+package glutil
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ShaderFS provides shader files by name.
+// Names use forward slashes, and includes are resolved relative to the including file.
+type ShaderFS interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// MapFS is a ShaderFS holding the files in memory, keyed by name.
+type MapFS map[string]string
+
+func (m MapFS) ReadFile(name string) ([]byte, error) {
+	source, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("shader file %s does not exist", name)
+	}
+	return []byte(source), nil
+}
+
+// ProcessedShader is a shader source with its includes and defines resolved.
+type ProcessedShader struct {
+	Source string
+	// Files are the names of the files the source was put together from.
+	// The index of a file is its source string number in the #line directives, and in compile errors.
+	Files []string
+	// Contents holds the contents of each file, by name.
+	Contents map[string]string
+}
+
+// Preprocessor resolves #include directives, and injects #defines, before a shader is compiled.
+//
+// An include is written as #include "name". A file starting with #pragma once is included only once per shader.
+// #line directives are inserted around every include, so line numbers in compile errors refer to
+// the original file; the file is identified by the source string number.
+type Preprocessor struct {
+	fs ShaderFS
+}
+
+func NewPreprocessor(fs ShaderFS) *Preprocessor {
+	return &Preprocessor{
+		fs: fs,
+	}
+}
+
+var (
+	includeDirective = regexp.MustCompile(`^\s*#\s*include\s+"([^"]+)"\s*(//.*)?$`)
+	versionDirective = regexp.MustCompile(`^\s*#\s*version\b`)
+	onceDirective    = regexp.MustCompile(`^\s*#\s*pragma\s+once\s*(//.*)?$`)
+)
+
+type preprocessState struct {
+	shader   *ProcessedShader
+	buf      *bytes.Buffer
+	stack    []string
+	included map[string]bool
+}
+
+// Process reads the named file, and returns it with its includes resolved and the defines added.
+// Defines are inserted after the #version directive, sorted by name; an empty value defines the name without one.
+func (p *Preprocessor) Process(name string, defines map[string]string) (*ProcessedShader, error) {
+	state := &preprocessState{
+		shader: &ProcessedShader{
+			Contents: make(map[string]string),
+		},
+		buf:      &bytes.Buffer{},
+		included: make(map[string]bool),
+	}
+	if err := p.include(state, name, defines); err != nil {
+		return nil, err
+	}
+	state.shader.Source = state.buf.String()
+	return state.shader, nil
+}
+
+func (p *Preprocessor) include(state *preprocessState, name string, defines map[string]string) error {
+	for _, parent := range state.stack {
+		if parent == name {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(state.stack, " -> "), name)
+		}
+	}
+	contents, err := p.fs.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("reading shader file %s: %w", name, err)
+	}
+	lines := strings.Split(string(contents), "\n")
+	if onceDirective.MatchString(lines[0]) {
+		if state.included[name] {
+			return nil
+		}
+		// Keep the line, so the line numbers stay the same.
+		lines[0] = ""
+	}
+	state.included[name] = true
+
+	index := len(state.shader.Files)
+	state.shader.Files = append(state.shader.Files, name)
+	state.shader.Contents[name] = string(contents)
+	state.stack = append(state.stack, name)
+	defer func() {
+		state.stack = state.stack[:len(state.stack)-1]
+	}()
+
+	offset := 0
+	if index > 0 {
+		directive(state.buf, 1, index)
+	} else if len(defines) > 0 {
+		// The defines go after #version, which must come first.
+		if versionDirective.MatchString(lines[0]) {
+			state.buf.WriteString(lines[0] + "\n")
+			offset = 1
+		}
+		writeDefines(state.buf, defines)
+		directive(state.buf, offset+1, index)
+	}
+	return p.includeLines(state, name, index, offset, lines[offset:])
+}
+
+// includeLines writes lines of the file with the given source string number, starting at the 0-based offset.
+func (p *Preprocessor) includeLines(state *preprocessState, name string, index, offset int, lines []string) error {
+	for i, line := range lines {
+		lineNumber := offset + i + 1
+		if m := includeDirective.FindStringSubmatch(line); m != nil {
+			includeName := path.Join(path.Dir(name), m[1])
+			if err := p.include(state, includeName, nil); err != nil {
+				return fmt.Errorf("%s:%d: %w", name, lineNumber, err)
+			}
+			directive(state.buf, lineNumber+1, index)
+			continue
+		}
+		if index > 0 && versionDirective.MatchString(line) {
+			return fmt.Errorf("%s:%d: #version in an included file", name, lineNumber)
+		}
+		state.buf.WriteString(line)
+		if i < len(lines)-1 {
+			state.buf.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+// directive writes a #line directive, which sets the line number and source string number of the next line.
+func directive(buf *bytes.Buffer, line, sourceString int) {
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(buf, "#line %d %d\n", line, sourceString)
+}
+
+func writeDefines(buf *bytes.Buffer, defines map[string]string) {
+	var names []string
+	for name := range defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := defines[name]; value != "" {
+			buf.WriteString("#define " + name + " " + value + "\n")
+		} else {
+			buf.WriteString("#define " + name + "\n")
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package glutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/gl"
+)
+
+var testShaderFS = MapFS{
+	"shaders/main.frag": `#version 300 es
+#include "common.glsl"
+#include "lib/light.glsl"
+out vec4 FragColor;
+void main() {
+	FragColor = light(Color);
+}`,
+	"shaders/common.glsl": `#pragma once
+precision mediump float;
+in vec4 Color;`,
+	"shaders/lib/light.glsl": `#include "../common.glsl"
+vec4 light(vec4 c) {
+	return c * LIGHT;
+}
+`,
+	"cycle/a.glsl": `#include "b.glsl"`,
+	"cycle/b.glsl": `#include "a.glsl"`,
+}
+
+func TestPreprocess(t *testing.T) {
+	pp := NewPreprocessor(testShaderFS)
+	shader, err := pp.Process("shaders/main.frag", map[string]string{"LIGHT": "0.5", "SHADOWS": ""})
+	if err != nil {
+		t.Fatalf("preprocessing: %v", err)
+	}
+	want := `#version 300 es
+#define LIGHT 0.5
+#define SHADOWS
+#line 2 0
+#line 1 1
+
+precision mediump float;
+in vec4 Color;
+#line 3 0
+#line 1 2
+#line 2 2
+vec4 light(vec4 c) {
+	return c * LIGHT;
+}
+#line 4 0
+out vec4 FragColor;
+void main() {
+	FragColor = light(Color);
+}`
+	if shader.Source != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, shader.Source)
+	}
+	wantFiles := []string{"shaders/main.frag", "shaders/common.glsl", "shaders/lib/light.glsl"}
+	if !reflect.DeepEqual(shader.Files, wantFiles) {
+		t.Fatalf("expected files %v, got: %v", wantFiles, shader.Files)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	pp := NewPreprocessor(testShaderFS)
+	if _, err := pp.Process("cycle/a.glsl", nil); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected an include cycle error, got: %v", err)
+	}
+	if _, err := pp.Process("missing.glsl", nil); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
+
+func TestPermutations(t *testing.T) {
+	var keys []string
+	for _, variant := range Permutations("A", "B") {
+		keys = append(keys, variant.Key())
+	}
+	if want := []string{"", "A", "B", "A,B"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected variant keys %v, got: %v", want, keys)
+	}
+	if key := (Variant{"N": "3", "A": ""}).Key(); key != "A,N=3" {
+		t.Fatalf("expected key A,N=3, got: %s", key)
+	}
+}
+
+func TestRemapProgramError(t *testing.T) {
+	pp := NewPreprocessor(testShaderFS)
+	shader, err := pp.Process("shaders/main.frag", nil)
+	if err != nil {
+		t.Fatalf("preprocessing: %v", err)
+	}
+	programErr := &gl.ProgramError{
+		Op: "compiling",
+		Diagnostics: []gl.ShaderDiagnostic{
+			{Stage: gl.FragmentShader, SourceString: 2, Line: 3, Severity: "error", Message: "'LIGHT' : undeclared identifier"},
+		},
+	}
+	remapProgramError(programErr, map[gl.ShaderType]*ProcessedShader{gl.FragmentShader: shader})
+	if file := programErr.Diagnostics[0].File; file != "shaders/lib/light.glsl" {
+		t.Fatalf("expected the diagnostic to point at shaders/lib/light.glsl, got: %s", file)
+	}
+	if !strings.Contains(programErr.Error(), "> 3 | \treturn c * LIGHT;") {
+		t.Fatalf("expected the error to show the line in the included file, got:\n%v", programErr)
+	}
+}
@@ -0,0 +1,131 @@
+package glutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PieterD/warp/pkg/gl"
+)
+
+// Variant selects a permutation of a program by the defines it is compiled with.
+type Variant map[string]string
+
+// Key returns a string that is the same for variants with the same defines.
+func (v Variant) Key() string {
+	var names []string
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		if value := v[name]; value != "" {
+			parts = append(parts, name+"="+value)
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Permutations returns every variant that defines some of the flags, starting with the one that defines none.
+func Permutations(flags ...string) []Variant {
+	variants := []Variant{{}}
+	for _, flag := range flags {
+		for _, variant := range variants {
+			withFlag := Variant{flag: ""}
+			for name, value := range variant {
+				withFlag[name] = value
+			}
+			variants = append(variants, withFlag)
+		}
+	}
+	return variants
+}
+
+// ProgramSource names the shader files a program is built from.
+type ProgramSource struct {
+	Vertex   string
+	Fragment string
+	// FeedbackVaryings and FeedbackInterleaved are passed on to gl.ProgramConfig.
+	FeedbackVaryings    []string
+	FeedbackInterleaved bool
+}
+
+// ProgramCache builds programs from preprocessed shader files, and keeps them by source and variant.
+type ProgramCache struct {
+	glx      *gl.Context
+	pp       *Preprocessor
+	programs map[string]gl.ProgramObject
+}
+
+func NewProgramCache(glx *gl.Context, pp *Preprocessor) *ProgramCache {
+	return &ProgramCache{
+		glx:      glx,
+		pp:       pp,
+		programs: make(map[string]gl.ProgramObject),
+	}
+}
+
+// Program returns the program built from src with the defines of variant, building it if it is not cached.
+// Compile errors are returned as a *gl.ProgramError, whose diagnostics point at the original files.
+// Failed builds are not cached.
+func (c *ProgramCache) Program(src ProgramSource, variant Variant) (gl.ProgramObject, error) {
+	key := fmt.Sprintf("%q %q %q %t %q", src.Vertex, src.Fragment, src.FeedbackVaryings, src.FeedbackInterleaved, variant.Key())
+	if program, ok := c.programs[key]; ok {
+		return program, nil
+	}
+	vertex, err := c.pp.Process(src.Vertex, variant)
+	if err != nil {
+		return gl.ProgramObject{}, fmt.Errorf("preprocessing vertex shader: %w", err)
+	}
+	fragment, err := c.pp.Process(src.Fragment, variant)
+	if err != nil {
+		return gl.ProgramObject{}, fmt.Errorf("preprocessing fragment shader: %w", err)
+	}
+	program, err := c.glx.BuildProgram(gl.ProgramConfig{
+		VertexSource:        vertex.Source,
+		FragmentSource:      fragment.Source,
+		FeedbackVaryings:    src.FeedbackVaryings,
+		FeedbackInterleaved: src.FeedbackInterleaved,
+	})
+	if err != nil {
+		var programErr *gl.ProgramError
+		if errors.As(err, &programErr) {
+			remapProgramError(programErr, map[gl.ShaderType]*ProcessedShader{
+				gl.VertexShader:   vertex,
+				gl.FragmentShader: fragment,
+			})
+		}
+		return gl.ProgramObject{}, fmt.Errorf("building program from %s and %s with variant %q: %w", src.Vertex, src.Fragment, variant.Key(), err)
+	}
+	c.programs[key] = program
+	return program, nil
+}
+
+// remapProgramError points the diagnostics at the files the shaders were put together from,
+// using the source string numbers set by the #line directives.
+func remapProgramError(err *gl.ProgramError, shaders map[gl.ShaderType]*ProcessedShader) {
+	if err.Files == nil {
+		err.Files = make(map[string]string)
+	}
+	for i, d := range err.Diagnostics {
+		shader, ok := shaders[d.Stage]
+		if !ok || d.SourceString < 0 || d.SourceString >= len(shader.Files) {
+			continue
+		}
+		name := shader.Files[d.SourceString]
+		err.Diagnostics[i].File = name
+		err.Files[name] = shader.Contents[name]
+	}
+}
+
+// Destroy destroys all cached programs.
+func (c *ProgramCache) Destroy() {
+	for key, program := range c.programs {
+		program.Destroy()
+		delete(c.programs, key)
+	}
+}
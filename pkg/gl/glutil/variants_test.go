@@ -0,0 +1,88 @@
+package glutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+	"github.com/PieterD/warp/pkg/gl"
+)
+
+type fakeCanvas struct {
+	factory *fakejs.Factory
+	obj     *fakejs.Object
+}
+
+func (c fakeCanvas) Driver() (factory driver.Factory, obj driver.Object) {
+	return c.factory, c.obj
+}
+
+// newFakeContext creates a Context on a fake WebGL2 context, like the one in the gl tests,
+// on which every shader compiles and every program links.
+// The names of the constants and functions are read from the type of the Context's unexported constants.
+func newFakeContext(t *testing.T) (*gl.Context, *fakejs.Factory) {
+	factory := fakejs.Open()
+	glObj := factory.Object()
+	field, ok := reflect.TypeOf(gl.Context{}).FieldByName("constants")
+	if !ok {
+		t.Fatalf("gl.Context has no constants field")
+	}
+	for i := 0; i < field.Type.NumField(); i++ {
+		constant := field.Type.Field(i)
+		if constant.Type.Kind() != reflect.Func {
+			glObj.Set(constant.Name, factory.Number(float64(0x1000+i)))
+			continue
+		}
+		functionName := strings.ToLower(constant.Name[:1]) + constant.Name[1:]
+		glObj.SetFunc(functionName, func(this driver.Object, args ...driver.Value) driver.Value {
+			switch {
+			case strings.HasPrefix(functionName, "create"):
+				return factory.Object()
+			case functionName == "getShaderParameter" || functionName == "getProgramParameter":
+				return factory.Boolean(true)
+			}
+			return factory.Undefined()
+		})
+	}
+	canvasObj := factory.Object()
+	canvasObj.SetFunc("getContext", func(this driver.Object, args ...driver.Value) driver.Value {
+		return glObj
+	})
+	glx, err := gl.NewContextConfig(fakeCanvas{factory: factory, obj: canvasObj}, gl.ContextConfig{})
+	if err != nil {
+		t.Fatalf("creating context: %v", err)
+	}
+	return glx, factory
+}
+
+func TestProgramCache(t *testing.T) {
+	glx, factory := newFakeContext(t)
+	cache := NewProgramCache(glx, NewPreprocessor(MapFS{
+		"feedback.vert": "#version 300 es\nout vec3 a;\nout vec3 b;\nvoid main() {}",
+		"feedback.frag": "#version 300 es\nvoid main() {}",
+	}))
+	defer cache.Destroy()
+	build := func(src ProgramSource) gl.ProgramObject {
+		program, err := cache.Program(src, nil)
+		if err != nil {
+			t.Fatalf("building program: %v", err)
+		}
+		return program
+	}
+	src := ProgramSource{Vertex: "feedback.vert", Fragment: "feedback.frag", FeedbackVaryings: []string{"a"}}
+	first := build(src)
+	if again := build(src); again != first {
+		t.Fatalf("expected the same source to return the cached program")
+	}
+	src.FeedbackVaryings = []string{"a", "b"}
+	if second := build(src); second == first {
+		t.Fatalf("expected different feedback varyings to build another program")
+	}
+	src.FeedbackInterleaved = true
+	build(src)
+	if calls := factory.CallsTo("createProgram"); len(calls) != 3 {
+		t.Fatalf("expected 3 programs to be built, got: %d", len(calls))
+	}
+}
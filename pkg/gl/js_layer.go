@@ -19,39 +19,80 @@ type glConstants struct {
 	Flush                    func(args ...driver.Value) driver.Value
 	Finish                   func(args ...driver.Value) driver.Value
 	CreateShader             func(args ...driver.Value) driver.Value
-	DeleteShader             func(args ...driver.Value) driver.Value
-	ShaderSource             func(args ...driver.Value) driver.Value
-	CompileShader            func(args ...driver.Value) driver.Value
+	DeleteShader             func(args ...driver.Value) driver.Value `gl:"batch"`
+	ShaderSource             func(args ...driver.Value) driver.Value `gl:"batch"`
+	CompileShader            func(args ...driver.Value) driver.Value `gl:"batch"`
 	GetShaderParameter       func(args ...driver.Value) driver.Value
 	GetShaderInfoLog         func(args ...driver.Value) driver.Value
 	CreateProgram            func(args ...driver.Value) driver.Value
-	DeleteProgram            func(args ...driver.Value) driver.Value
-	AttachShader             func(args ...driver.Value) driver.Value
-	LinkProgram              func(args ...driver.Value) driver.Value
+	DeleteProgram            func(args ...driver.Value) driver.Value `gl:"batch"`
+	AttachShader             func(args ...driver.Value) driver.Value `gl:"batch"`
+	LinkProgram              func(args ...driver.Value) driver.Value `gl:"batch"`
 	GetProgramParameter      func(args ...driver.Value) driver.Value
 	GetProgramInfoLog        func(args ...driver.Value) driver.Value
-	UseProgram               func(args ...driver.Value) driver.Value
+	UseProgram               func(args ...driver.Value) driver.Value `gl:"batch"`
 	GetAttribLocation        func(args ...driver.Value) driver.Value
 	GetUniformLocation       func(args ...driver.Value) driver.Value
 	GetUniformBlockIndex     func(args ...driver.Value) driver.Value
-	UniformBlockBinding      func(args ...driver.Value) driver.Value
-	GetActiveAttrib          func(args ...driver.Value) driver.Value
-	GetActiveUniform         func(args ...driver.Value) driver.Value
+	UniformBlockBinding      func(args ...driver.Value) driver.Value `gl:"batch"`
 	CreateVertexArray        func(args ...driver.Value) driver.Value
-	DeleteVertexArray        func(args ...driver.Value) driver.Value
-	BindVertexArray          func(args ...driver.Value) driver.Value
-	Uniform1i                func(args ...driver.Value) driver.Value
-	Uniform1f                func(args ...driver.Value) driver.Value
-	Uniform2f                func(args ...driver.Value) driver.Value
-	Uniform3f                func(args ...driver.Value) driver.Value
-	Uniform4f                func(args ...driver.Value) driver.Value
-	UniformMatrix4fv         func(args ...driver.Value) driver.Value
-	VertexAttribPointer      func(args ...driver.Value) driver.Value
-	EnableVertexAttribArray  func(args ...driver.Value) driver.Value
-	DisableVertexAttribArray func(args ...driver.Value) driver.Value
-	ClearColor               func(args ...driver.Value) driver.Value
-	Viewport                 func(args ...driver.Value) driver.Value
-	VertexAttribDivisor      func(args ...driver.Value) driver.Value
+	DeleteVertexArray        func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindVertexArray          func(args ...driver.Value) driver.Value `gl:"batch"`
+	VertexAttribPointer      func(args ...driver.Value) driver.Value `gl:"batch"`
+	EnableVertexAttribArray  func(args ...driver.Value) driver.Value `gl:"batch"`
+	DisableVertexAttribArray func(args ...driver.Value) driver.Value `gl:"batch"`
+	ClearColor               func(args ...driver.Value) driver.Value `gl:"batch"`
+	Viewport                 func(args ...driver.Value) driver.Value `gl:"batch"`
+	VertexAttribDivisor      func(args ...driver.Value) driver.Value `gl:"batch"`
+
+	/* Program reflection. */
+
+	ACTIVE_ATTRIBUTES                    driver.Value
+	ACTIVE_UNIFORMS                      driver.Value
+	ACTIVE_UNIFORM_BLOCKS                driver.Value
+	UNIFORM_BLOCK_INDEX                  driver.Value
+	UNIFORM_OFFSET                       driver.Value
+	UNIFORM_ARRAY_STRIDE                 driver.Value
+	UNIFORM_MATRIX_STRIDE                driver.Value
+	UNIFORM_IS_ROW_MAJOR                 driver.Value
+	UNIFORM_BLOCK_BINDING                driver.Value
+	UNIFORM_BLOCK_DATA_SIZE              driver.Value
+	UNIFORM_BLOCK_ACTIVE_UNIFORM_INDICES driver.Value
+	GetActiveAttrib                      func(args ...driver.Value) driver.Value
+	GetActiveUniform                     func(args ...driver.Value) driver.Value
+	GetActiveUniforms                    func(args ...driver.Value) driver.Value
+	GetActiveUniformBlockParameter       func(args ...driver.Value) driver.Value
+	GetActiveUniformBlockName            func(args ...driver.Value) driver.Value
+
+	/* Uniforms. */
+
+	Uniform1f        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform2f        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform3f        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform4f        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform1i        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform2i        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform3i        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform4i        func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform1ui       func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform2ui       func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform3ui       func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform4ui       func(args ...driver.Value) driver.Value `gl:"batch"`
+	Uniform1fv       func(args ...driver.Value) driver.Value
+	Uniform2fv       func(args ...driver.Value) driver.Value
+	Uniform3fv       func(args ...driver.Value) driver.Value
+	Uniform4fv       func(args ...driver.Value) driver.Value
+	Uniform1iv       func(args ...driver.Value) driver.Value
+	Uniform2iv       func(args ...driver.Value) driver.Value
+	Uniform3iv       func(args ...driver.Value) driver.Value
+	Uniform4iv       func(args ...driver.Value) driver.Value
+	Uniform1uiv      func(args ...driver.Value) driver.Value
+	Uniform2uiv      func(args ...driver.Value) driver.Value
+	Uniform3uiv      func(args ...driver.Value) driver.Value
+	Uniform4uiv      func(args ...driver.Value) driver.Value
+	UniformMatrix2fv func(args ...driver.Value) driver.Value
+	UniformMatrix3fv func(args ...driver.Value) driver.Value
+	UniformMatrix4fv func(args ...driver.Value) driver.Value
 
 	/* Sync */
 
@@ -63,8 +104,8 @@ type glConstants struct {
 	WAIT_FAILED                driver.Value
 	TIMEOUT_IGNORED            driver.Value
 	FenceSync                  func(args ...driver.Value) driver.Value
-	DeleteSync                 func(args ...driver.Value) driver.Value
-	WaitSync                   func(args ...driver.Value) driver.Value
+	DeleteSync                 func(args ...driver.Value) driver.Value `gl:"batch"`
+	WaitSync                   func(args ...driver.Value) driver.Value `gl:"batch"`
 	ClientWaitSync             func(args ...driver.Value) driver.Value
 
 	/* Alpha blending. */
@@ -84,22 +125,22 @@ type glConstants struct {
 	FUNC_REVERSE_SUBTRACT driver.Value
 	MIN                   driver.Value
 	MAX                   driver.Value
-	BlendFunc             func(args ...driver.Value) driver.Value
-	BlendEquation         func(args ...driver.Value) driver.Value
+	BlendFunc             func(args ...driver.Value) driver.Value `gl:"batch"`
+	BlendEquation         func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Features. */
 
 	BLEND              driver.Value
 	CULL_FACE          driver.Value
 	RASTERIZER_DISCARD driver.Value
-	Enable             func(args ...driver.Value) driver.Value
-	Disable            func(args ...driver.Value) driver.Value
+	Enable             func(args ...driver.Value) driver.Value `gl:"batch"`
+	Disable            func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Face culling */
 
 	FRONT    driver.Value
 	BACK     driver.Value
-	CullFace func(args ...driver.Value) driver.Value
+	CullFace func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Depth. */
 
@@ -110,8 +151,8 @@ type glConstants struct {
 	GREATER   driver.Value
 	GEQUAL    driver.Value
 	NOTEQUAL  driver.Value
-	DepthMask func(args ...driver.Value) driver.Value
-	DepthFunc func(args ...driver.Value) driver.Value
+	DepthMask func(args ...driver.Value) driver.Value `gl:"batch"`
+	DepthFunc func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Parameters. */
 
@@ -124,33 +165,44 @@ type glConstants struct {
 
 	COLOR_BUFFER_BIT driver.Value
 	DEPTH_BUFFER_BIT driver.Value
-	Clear            func(args ...driver.Value) driver.Value
+	Clear            func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Drawing. */
 
 	POINTS                driver.Value
 	LINES                 driver.Value
 	TRIANGLES             driver.Value
-	DrawArrays            func(args ...driver.Value) driver.Value
-	DrawElements          func(args ...driver.Value) driver.Value
-	DrawArraysInstanced   func(args ...driver.Value) driver.Value
-	DrawElementsInstanced func(args ...driver.Value) driver.Value
+	DrawArrays            func(args ...driver.Value) driver.Value `gl:"batch"`
+	DrawElements          func(args ...driver.Value) driver.Value `gl:"batch"`
+	DrawArraysInstanced   func(args ...driver.Value) driver.Value `gl:"batch"`
+	DrawElementsInstanced func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Data types. */
 
-	FLOAT          driver.Value
-	FLOAT_VEC2     driver.Value
-	FLOAT_VEC3     driver.Value
-	FLOAT_VEC4     driver.Value
-	FLOAT_MAT2     driver.Value
-	FLOAT_MAT3     driver.Value
-	FLOAT_MAT4     driver.Value
-	BYTE           driver.Value
-	UNSIGNED_BYTE  driver.Value
-	SHORT          driver.Value
-	UNSIGNED_SHORT driver.Value
-	INT            driver.Value
-	UNSIGNED_INT   driver.Value
+	FLOAT             driver.Value
+	FLOAT_VEC2        driver.Value
+	FLOAT_VEC3        driver.Value
+	FLOAT_VEC4        driver.Value
+	FLOAT_MAT2        driver.Value
+	FLOAT_MAT3        driver.Value
+	FLOAT_MAT4        driver.Value
+	BYTE              driver.Value
+	UNSIGNED_BYTE     driver.Value
+	SHORT             driver.Value
+	UNSIGNED_SHORT    driver.Value
+	INT               driver.Value
+	UNSIGNED_INT      driver.Value
+	INT_VEC2          driver.Value
+	INT_VEC3          driver.Value
+	INT_VEC4          driver.Value
+	UNSIGNED_INT_VEC2 driver.Value
+	UNSIGNED_INT_VEC3 driver.Value
+	UNSIGNED_INT_VEC4 driver.Value
+	BOOL              driver.Value
+	SAMPLER_2D        driver.Value
+	SAMPLER_3D        driver.Value
+	SAMPLER_CUBE      driver.Value
+	SAMPLER_2D_ARRAY  driver.Value
 
 	/* Buffer stuff */
 
@@ -167,12 +219,13 @@ type glConstants struct {
 	ELEMENT_ARRAY_BUFFER      driver.Value
 	UNIFORM_BUFFER            driver.Value
 	TRANSFORM_FEEDBACK_BUFFER driver.Value
+	PIXEL_PACK_BUFFER         driver.Value
 	CreateBuffer              func(args ...driver.Value) driver.Value
-	DeleteBuffer              func(args ...driver.Value) driver.Value
-	BindBuffer                func(args ...driver.Value) driver.Value
+	DeleteBuffer              func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindBuffer                func(args ...driver.Value) driver.Value `gl:"batch"`
 	BufferData                func(args ...driver.Value) driver.Value
-	BindBufferBase            func(args ...driver.Value) driver.Value
-	BindBufferRange           func(args ...driver.Value) driver.Value
+	BindBufferBase            func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindBufferRange           func(args ...driver.Value) driver.Value `gl:"batch"`
 	GetBufferSubData          func(args ...driver.Value) driver.Value
 
 	/* Transform feedback */
@@ -182,11 +235,11 @@ type glConstants struct {
 	SEPARATE_ATTRIBS                      driver.Value
 	TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN driver.Value
 	CreateTransformFeedback               func(args ...driver.Value) driver.Value
-	DeleteTransformFeedback               func(args ...driver.Value) driver.Value
-	BindTransformFeedback                 func(args ...driver.Value) driver.Value
-	TransformFeedbackVaryings             func(args ...driver.Value) driver.Value
-	BeginTransformFeedback                func(args ...driver.Value) driver.Value
-	EndTransformFeedback                  func(args ...driver.Value) driver.Value
+	DeleteTransformFeedback               func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindTransformFeedback                 func(args ...driver.Value) driver.Value `gl:"batch"`
+	TransformFeedbackVaryings             func(args ...driver.Value) driver.Value `gl:"batch"`
+	BeginTransformFeedback                func(args ...driver.Value) driver.Value `gl:"batch"`
+	EndTransformFeedback                  func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Internal formats */
 
@@ -200,6 +253,11 @@ type glConstants struct {
 	/* Texture stuff */
 
 	RGBA               driver.Value
+	RED                driver.Value
+	RG                 driver.Value
+	RGBA_INTEGER       driver.Value
+	RED_INTEGER        driver.Value
+	RG_INTEGER         driver.Value
 	TEXTURE_2D         driver.Value
 	TEXTURE_MIN_FILTER driver.Value
 	TEXTURE_MAG_FILTER driver.Value
@@ -211,34 +269,37 @@ type glConstants struct {
 	CLAMP_TO_EDGE      driver.Value
 	MIRRORED_REPEAT    driver.Value
 	TEXTURE0           driver.Value
-	ActiveTexture      func(args ...driver.Value) driver.Value
+	ActiveTexture      func(args ...driver.Value) driver.Value `gl:"batch"`
 	CreateTexture      func(args ...driver.Value) driver.Value
-	DeleteTexture      func(args ...driver.Value) driver.Value
-	BindTexture        func(args ...driver.Value) driver.Value
-	TexParameteri      func(args ...driver.Value) driver.Value
+	DeleteTexture      func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindTexture        func(args ...driver.Value) driver.Value `gl:"batch"`
+	TexParameteri      func(args ...driver.Value) driver.Value `gl:"batch"`
+	TexParameterf      func(args ...driver.Value) driver.Value `gl:"batch"`
 	TexImage2D         func(args ...driver.Value) driver.Value
 	TexSubImage2D      func(args ...driver.Value) driver.Value
-	GenerateMipmap     func(args ...driver.Value) driver.Value
+	GenerateMipmap     func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Query object stuff */
 
-	QUERY_RESULT           driver.Value
-	QUERY_RESULT_AVAILABLE driver.Value
-	CreateQuery            func(args ...driver.Value) driver.Value
-	DeleteQuery            func(args ...driver.Value) driver.Value
-	BeginQuery             func(args ...driver.Value) driver.Value
-	EndQuery               func(args ...driver.Value) driver.Value
-	GetQuery               func(args ...driver.Value) driver.Value
-	GetQueryParameter      func(args ...driver.Value) driver.Value
+	QUERY_RESULT                    driver.Value
+	QUERY_RESULT_AVAILABLE          driver.Value
+	ANY_SAMPLES_PASSED              driver.Value
+	ANY_SAMPLES_PASSED_CONSERVATIVE driver.Value
+	CreateQuery                     func(args ...driver.Value) driver.Value
+	DeleteQuery                     func(args ...driver.Value) driver.Value `gl:"batch"`
+	BeginQuery                      func(args ...driver.Value) driver.Value `gl:"batch"`
+	EndQuery                        func(args ...driver.Value) driver.Value `gl:"batch"`
+	GetQuery                        func(args ...driver.Value) driver.Value
+	GetQueryParameter               func(args ...driver.Value) driver.Value
 
 	/* Renderbuffer stuff */
 
 	RENDERBUFFER                   driver.Value
 	CreateRenderbuffer             func(args ...driver.Value) driver.Value
-	DeleteRenderbuffer             func(args ...driver.Value) driver.Value
-	BindRenderbuffer               func(args ...driver.Value) driver.Value
-	RenderbufferStorage            func(args ...driver.Value) driver.Value
-	RenderbufferStorageMultisample func(args ...driver.Value) driver.Value
+	DeleteRenderbuffer             func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindRenderbuffer               func(args ...driver.Value) driver.Value `gl:"batch"`
+	RenderbufferStorage            func(args ...driver.Value) driver.Value `gl:"batch"`
+	RenderbufferStorageMultisample func(args ...driver.Value) driver.Value `gl:"batch"`
 
 	/* Framebuffer stuff */
 
@@ -247,19 +308,58 @@ type glConstants struct {
 	FRAMEBUFFER              driver.Value
 	FRAMEBUFFER_COMPLETE     driver.Value
 	CreateFramebuffer        func(args ...driver.Value) driver.Value
-	DeleteFramebuffer        func(args ...driver.Value) driver.Value
-	BindFramebuffer          func(args ...driver.Value) driver.Value
-	FramebufferRenderbuffer  func(args ...driver.Value) driver.Value
+	DeleteFramebuffer        func(args ...driver.Value) driver.Value `gl:"batch"`
+	BindFramebuffer          func(args ...driver.Value) driver.Value `gl:"batch"`
+	FramebufferRenderbuffer  func(args ...driver.Value) driver.Value `gl:"batch"`
 	CheckFramebufferStatus   func(args ...driver.Value) driver.Value
 	ReadPixels               func(args ...driver.Value) driver.Value
+
+	/* Errors. */
+
+	NO_ERROR                      driver.Value
+	INVALID_ENUM                  driver.Value
+	INVALID_VALUE                 driver.Value
+	INVALID_OPERATION             driver.Value
+	INVALID_FRAMEBUFFER_OPERATION driver.Value
+	OUT_OF_MEMORY                 driver.Value
+	CONTEXT_LOST_WEBGL            driver.Value
+	GetError                      func(args ...driver.Value) driver.Value
+
+	/* Extensions. */
+
+	GetSupportedExtensions func(args ...driver.Value) driver.Value
+	GetExtension           func(args ...driver.Value) driver.Value
+}
+
+// glBinding holds the wrappers applied to every bound GL function.
+// If commands is not nil, functions tagged with gl:"batch" are recorded in it,
+// and all other functions flush it before they are called.
+// If debug is not nil, getError is checked after every other function.
+// If trace is not nil, all functions are traced.
+// If webgl1 is not nil, the bound object is a WebGL1 context; WebGL2 constants are left undefined,
+// and WebGL2 functions are provided by webgl1.
+type glBinding struct {
+	trace    *tracer
+	commands *commandBuffer
+	debug    *debugger
+	webgl1   *webgl1Fallback
 }
 
-func newGlConstants(obj driver.Object, trace bool) (c glConstants) {
+func newGlConstants(obj driver.Object, binding glBinding) (c glConstants) {
+	binding.bind(obj, &c)
+	return c
+}
+
+// bind loads the constants and functions of obj into the struct pointed to by ptr.
+// Fields of type driver.Value are constants, and fields of type func(args ...driver.Value) driver.Value
+// are bound to the method of the same name, with the first letter in lower case.
+func (binding glBinding) bind(obj driver.Object, ptr interface{}) {
+	trace, commands, debug, webgl1 := binding.trace, binding.commands, binding.debug, binding.webgl1
 	var driverValue driver.Value
 	var driverFunc func(args ...driver.Value) driver.Value
 	typeDriverValue := reflect.TypeOf(&driverValue).Elem()
 	typeDriverFunc := reflect.TypeOf(&driverFunc).Elem()
-	v := reflect.ValueOf(&c).Elem()
+	v := reflect.ValueOf(ptr).Elem()
 	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
 		fieldValue := v.Field(i)
@@ -268,7 +368,7 @@ func newGlConstants(obj driver.Object, trace bool) (c glConstants) {
 		switch fieldValue.Type() {
 		case typeDriverValue:
 			_, ok := value.ToFloat64()
-			if !ok {
+			if !ok && webgl1 == nil {
 				panic(fmt.Errorf("constant %s is apparently not a value", field.Name))
 			}
 			//fmt.Printf("loading constant: %s = %d\n", field.Name, int(v))
@@ -277,49 +377,33 @@ func newGlConstants(obj driver.Object, trace bool) (c glConstants) {
 			functionName := strings.ToLower(field.Name[:1]) + field.Name[1:]
 			//fmt.Printf("loading function: %s\n", functionName)
 			function := driver.Bind(obj, functionName)
+			native := function != nil
+			if webgl1 != nil {
+				if !native {
+					function = webgl1.function(functionName)
+				}
+				function = webgl1.adapt(functionName, function)
+			}
 			if function == nil {
 				panic(fmt.Errorf("function %s is apparently not a function", functionName))
 			}
-			if trace {
-				function = wrapTrace(functionName, function)
+			if commands != nil {
+				// The replay function can only call functions of the context itself.
+				if native && field.Tag.Get("gl") == "batch" {
+					function = commands.recorder(commands.register(functionName), function)
+				} else {
+					function = commands.flusher(function)
+				}
+			}
+			if debug != nil && functionName != "getError" {
+				function = debug.wrap(functionName, function)
+			}
+			if trace != nil {
+				function = trace.wrap(functionName, function)
 			}
 			fieldValue.Set(reflect.ValueOf(function))
 		default:
 			panic(fmt.Errorf("unhandled type: %v", fieldValue.Type()))
 		}
 	}
-	return c
-}
-
-func wrapTrace(functionName string, f func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
-	return func(args ...driver.Value) driver.Value {
-		fmt.Printf("[TRACE] %s(", functionName)
-		for i, arg := range args {
-			isLastArg := i == len(args)-1
-			if arg.IsNull() {
-				fmt.Printf("null")
-			} else if arg.IsUndefined() {
-				fmt.Printf("undefined")
-			} else if v, ok := arg.ToBoolean(); ok {
-				fmt.Printf("%t", v)
-			} else if v, ok := arg.ToFloat64(); ok {
-				fmt.Printf("%f", v)
-			} else if v, ok := arg.ToString(); ok {
-				fmt.Printf("%q", v)
-			} else if _, ok := arg.ToObject(); ok {
-				fmt.Printf("object")
-			} else if _, ok := arg.ToFunction(); ok {
-				fmt.Printf("function")
-			} else {
-				fmt.Printf("UNKNOWN")
-			}
-			if !isLastArg {
-				fmt.Printf(", ")
-			}
-		}
-		fmt.Printf(")\n")
-		rv := f(args...)
-		return rv
-	}
-
 }
@@ -0,0 +1,179 @@
+package gl
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// contextLoss tracks whether the GL context has been lost,
+// and holds the callbacks that recreate resources once it has been restored.
+// Event callbacks may run on another goroutine, depending on the driver.
+type contextLoss struct {
+	lock sync.Mutex
+	lost bool
+	// generation counts the times the context was lost; objects created before the last loss are invalid.
+	generation int
+	lastID     int
+	restorers  []restorer
+	onLost     []restorer
+	deregister func()
+}
+
+type restorer struct {
+	id int
+	f  func()
+}
+
+// listenContextLoss registers the webglcontextlost and webglcontextrestored handlers on the canvas.
+// It does nothing if the canvas does not support event listeners.
+func (glx *Context) listenContextLoss(canvasObject driver.Object) {
+	addEventListener := driver.Bind(canvasObject, "addEventListener")
+	if addEventListener == nil {
+		return
+	}
+	lostName := glx.factory.String("webglcontextlost")
+	lostCallback := glx.factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
+		// Without preventDefault, the browser will never restore the context.
+		if len(args) > 0 {
+			if event, ok := args[0].ToObject(); ok {
+				if preventDefault := driver.Bind(event, "preventDefault"); preventDefault != nil {
+					preventDefault()
+				}
+			}
+		}
+		glx.contextLost()
+		return nil
+	})
+	restoredName := glx.factory.String("webglcontextrestored")
+	restoredCallback := glx.factory.Function(func(this driver.Object, args ...driver.Value) driver.Value {
+		glx.contextRestored()
+		return nil
+	})
+	addEventListener(lostName, lostCallback)
+	addEventListener(restoredName, restoredCallback)
+	glx.loss.deregister = func() {
+		removeEventListener := driver.Bind(canvasObject, "removeEventListener")
+		removeEventListener(lostName, lostCallback)
+		removeEventListener(restoredName, restoredCallback)
+		lostCallback.Release()
+		restoredCallback.Release()
+	}
+}
+
+func (glx *Context) contextLost() {
+	glx.loss.lock.Lock()
+	glx.loss.lost = true
+	glx.loss.generation++
+	callbacks := append([]restorer(nil), glx.loss.onLost...)
+	glx.loss.lock.Unlock()
+	if glx.commands != nil {
+		// The recorded commands refer to objects of the lost context.
+		glx.commands.discard()
+	}
+	for _, callback := range callbacks {
+		callback.f()
+	}
+}
+
+func (glx *Context) contextRestored() {
+	glx.loss.lock.Lock()
+	glx.loss.lost = false
+	callbacks := append([]restorer(nil), glx.loss.restorers...)
+	glx.loss.lock.Unlock()
+	// The restored context starts out with the default state.
+	glx.state.reset()
+	for _, callback := range callbacks {
+		callback.f()
+	}
+}
+
+// IsLost returns true if the browser has lost the GL context, and it has not been restored yet.
+// All resources become invalid when the context is lost, and GL calls do nothing until it is restored.
+func (glx *Context) IsLost() bool {
+	glx.loss.lock.Lock()
+	defer glx.loss.lock.Unlock()
+	return glx.loss.lost
+}
+
+// valid returns true if an object created in the given generation belongs to the current context.
+func (glx *Context) valid(generation int) bool {
+	glx.loss.lock.Lock()
+	defer glx.loss.lock.Unlock()
+	return !glx.loss.lost && glx.loss.generation == generation
+}
+
+func (glx *Context) generation() int {
+	glx.loss.lock.Lock()
+	defer glx.loss.lock.Unlock()
+	return glx.loss.generation
+}
+
+// OnRestore registers a callback that is called after the context has been restored.
+// It should recreate the resources it is responsible for, and upload their contents again.
+// Buffers, textures, programs and the other GL objects are not recreated by the Context.
+// Profilers and occlusion queries create new queries by themselves; fences and pixel readbacks
+// that were pending when the context was lost fail, and have to be started again.
+// Callbacks are called in the order they were registered, so resources depending on others
+// should be registered later.
+func (glx *Context) OnRestore(restore func()) (deregister func()) {
+	return glx.loss.register(&glx.loss.restorers, restore)
+}
+
+// OnLost registers a callback that is called when the context is lost.
+func (glx *Context) OnLost(lost func()) (deregister func()) {
+	return glx.loss.register(&glx.loss.onLost, lost)
+}
+
+func (loss *contextLoss) register(list *[]restorer, f func()) (deregister func()) {
+	loss.lock.Lock()
+	defer loss.lock.Unlock()
+	loss.lastID++
+	id := loss.lastID
+	*list = append(*list, restorer{id: id, f: f})
+	return func() {
+		loss.lock.Lock()
+		defer loss.lock.Unlock()
+		for i, r := range *list {
+			if r.id == id {
+				*list = append((*list)[:i:i], (*list)[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// loseContextExtension returns the WEBGL_lose_context extension, or nil if it is not supported.
+func (glx *Context) loseContextExtension() driver.Object {
+	extensionObject, ok := glx.extension("WEBGL_lose_context")
+	if !ok {
+		return nil
+	}
+	return extensionObject
+}
+
+// SimulateLoss makes the browser lose the context, to test restoration.
+// SimulateRestore restores it again.
+func (glx *Context) SimulateLoss() error {
+	return glx.callLoseContext("loseContext")
+}
+
+func (glx *Context) SimulateRestore() error {
+	return glx.callLoseContext("restoreContext")
+}
+
+func (glx *Context) callLoseContext(method string) error {
+	extension := glx.loseContextExtension()
+	if extension == nil {
+		return fmt.Errorf("WEBGL_lose_context is not supported")
+	}
+	f, err := driver.BindErr(extension, method)
+	if err != nil {
+		return err
+	}
+	if _, err := f(); err != nil {
+		return fmt.Errorf("calling %s: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package gl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestContextLoss(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("getExtension", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Null()
+		})
+	})
+	listeners := make(map[string]driver.Function)
+	canvas.obj.SetFunc("addEventListener", func(this driver.Object, args ...driver.Value) driver.Value {
+		name, _ := args[0].ToString()
+		listeners[name], _ = args[1].ToFunction()
+		return canvas.factory.Undefined()
+	})
+	canvas.obj.SetFunc("removeEventListener", func(this driver.Object, args ...driver.Value) driver.Value {
+		name, _ := args[0].ToString()
+		delete(listeners, name)
+		return canvas.factory.Undefined()
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	if len(listeners) != 2 {
+		t.Fatalf("expected 2 event listeners, got: %d", len(listeners))
+	}
+
+	var events []string
+	glx.OnLost(func() { events = append(events, "lost") })
+	glx.OnRestore(func() { events = append(events, "first") })
+	deregister := glx.OnRestore(func() { events = append(events, "deregistered") })
+	glx.OnRestore(func() { events = append(events, "second") })
+	deregister()
+
+	event := canvas.factory.Object()
+	event.SetFunc("preventDefault", func(this driver.Object, args ...driver.Value) driver.Value {
+		return canvas.factory.Undefined()
+	})
+	listeners["webglcontextlost"].Call(nil, event)
+	if !glx.IsLost() {
+		t.Fatalf("expected the context to be lost")
+	}
+	if calls := canvas.factory.CallsTo("preventDefault"); len(calls) != 1 {
+		t.Fatalf("expected preventDefault to be called, got %d calls", len(calls))
+	}
+	listeners["webglcontextrestored"].Call(nil, canvas.factory.Object())
+	if glx.IsLost() {
+		t.Fatalf("expected the context to be restored")
+	}
+	if want := []string{"lost", "first", "second"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected events %v, got: %v", want, events)
+	}
+
+	glx.Destroy()
+	if len(listeners) != 0 {
+		t.Fatalf("expected the event listeners to be removed, got: %d", len(listeners))
+	}
+	if !glx.IsLost() {
+		t.Fatalf("expected a destroyed context to be lost")
+	}
+	if canvas.factory.LiveCallbacks() != 0 {
+		t.Fatalf("expected the event callbacks to be released, got: %d", canvas.factory.LiveCallbacks())
+	}
+}
@@ -0,0 +1,122 @@
+package gl
+
+// OcclusionQuery tracks whether a single object is visible, without waiting for the GPU.
+//
+// Every frame, draw the object, or a cheap stand-in like its bounding box, between Begin and End.
+// Results arrive a few frames later, so a ring of queries is kept in flight,
+// and Visible reports the most recent result that has arrived.
+//
+// While the context is lost, Begin returns false and Visible keeps reporting the last result.
+// The queries are created again when it is restored.
+type OcclusionQuery struct {
+	glx      *Context
+	target   QueryTarget
+	queries  []QueryObject
+	inFlight []int
+	next     int
+	active   bool
+	visible  bool
+	known    bool
+	lost     bool
+	// deregister removes the context loss callbacks.
+	deregister []func()
+}
+
+// NewOcclusionQuery creates an occlusion query with a ring of size queries.
+// A conservative query is faster, but may report an object as visible when it is not.
+func (glx *Context) NewOcclusionQuery(conservative bool, size int) *OcclusionQuery {
+	target := glx.Targets().QueryAnySamplesPassed()
+	if conservative {
+		target = glx.Targets().QueryAnySamplesPassedConservative()
+	}
+	if size < 1 {
+		size = 1
+	}
+	oq := &OcclusionQuery{
+		glx:     glx,
+		target:  target,
+		queries: make([]QueryObject, size),
+	}
+	oq.createQueries()
+	oq.deregister = []func(){
+		glx.OnLost(func() {
+			oq.lost = true
+			oq.inFlight = nil
+			oq.active = false
+		}),
+		glx.OnRestore(func() {
+			oq.lost = false
+			oq.next = 0
+			oq.createQueries()
+		}),
+	}
+	return oq
+}
+
+func (oq *OcclusionQuery) createQueries() {
+	for i := range oq.queries {
+		oq.queries[i] = oq.glx.CreateQuery()
+	}
+}
+
+// Begin starts a query for this frame.
+// It returns false if all queries are still in flight, in which case End must not be called;
+// the object should then be drawn as if it were visible according to Visible.
+func (oq *OcclusionQuery) Begin() bool {
+	oq.poll()
+	if oq.lost || len(oq.inFlight) == len(oq.queries) {
+		return false
+	}
+	oq.target.Begin(oq.queries[oq.next])
+	oq.active = true
+	return true
+}
+
+// End ends the query started by Begin.
+func (oq *OcclusionQuery) End() {
+	if !oq.active {
+		return
+	}
+	oq.target.End()
+	oq.active = false
+	oq.inFlight = append(oq.inFlight, oq.next)
+	oq.next = (oq.next + 1) % len(oq.queries)
+}
+
+// Visible returns the most recent visibility result.
+// Until the first result has arrived, the object is assumed to be visible, and known is false.
+func (oq *OcclusionQuery) Visible() (visible, known bool) {
+	oq.poll()
+	if !oq.known {
+		return true, false
+	}
+	return oq.visible, true
+}
+
+// poll reads the results that have arrived, oldest first, without blocking.
+func (oq *OcclusionQuery) poll() {
+	if oq.lost {
+		return
+	}
+	for len(oq.inFlight) > 0 {
+		result, available := oq.queries[oq.inFlight[0]].Result()
+		if !available {
+			return
+		}
+		oq.visible = result != 0
+		oq.known = true
+		oq.inFlight = oq.inFlight[1:]
+	}
+}
+
+func (oq *OcclusionQuery) Destroy() {
+	for _, deregister := range oq.deregister {
+		deregister()
+	}
+	oq.deregister = nil
+	for _, query := range oq.queries {
+		query.Destroy()
+	}
+	oq.queries = nil
+	oq.inFlight = nil
+}
@@ -0,0 +1,98 @@
+package gl
+
+import (
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestOcclusionQuery(t *testing.T) {
+	available, samplesPassed := false, 0.0
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("getQueryParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if factory.Equal(args[1], glObj.Get("QUERY_RESULT_AVAILABLE")) {
+				return factory.Boolean(available)
+			}
+			return factory.Number(samplesPassed)
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	oq := glx.NewOcclusionQuery(true, 2)
+
+	for i := 0; i < 2; i++ {
+		if !oq.Begin() {
+			t.Fatalf("expected query %d to begin", i)
+		}
+		oq.End()
+	}
+	if oq.Begin() {
+		t.Fatalf("expected no query to begin while all are in flight")
+	}
+	if visible, known := oq.Visible(); !visible || known {
+		t.Fatalf("expected unknown visibility to be visible, got: %t, %t", visible, known)
+	}
+	calls := canvas.factory.CallsTo("beginQuery")
+	if len(calls) != 2 || !canvas.factory.Equal(calls[0].Args[0], glx.constants.ANY_SAMPLES_PASSED_CONSERVATIVE) {
+		t.Fatalf("expected 2 conservative queries, got: %v", calls)
+	}
+
+	available = true
+	if visible, known := oq.Visible(); visible || !known {
+		t.Fatalf("expected the object to be hidden, got: %t, %t", visible, known)
+	}
+	if !oq.Begin() {
+		t.Fatalf("expected a query to begin once results have arrived")
+	}
+	oq.End()
+	samplesPassed = 1
+	if visible, _ := oq.Visible(); !visible {
+		t.Fatalf("expected the object to be visible")
+	}
+	oq.Destroy()
+	if calls := canvas.factory.CallsTo("deleteQuery"); len(calls) != 2 {
+		t.Fatalf("expected 2 queries to be deleted, got: %d", len(calls))
+	}
+}
+
+func TestOcclusionQueryContextLoss(t *testing.T) {
+	lost := false
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("getQueryParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if lost {
+				return factory.Null()
+			}
+			if factory.Equal(args[1], glObj.Get("QUERY_RESULT_AVAILABLE")) {
+				return factory.Boolean(true)
+			}
+			return factory.Number(0)
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	oq := glx.NewOcclusionQuery(false, 2)
+	defer oq.Destroy()
+	oq.Begin()
+	oq.End()
+	if visible, known := oq.Visible(); visible || !known {
+		t.Fatalf("expected the object to be hidden, got: %t, %t", visible, known)
+	}
+	oq.Begin()
+	lost = true
+	glx.contextLost()
+	oq.End()
+	if oq.Begin() {
+		t.Fatalf("expected no query to begin while the context is lost")
+	}
+	if visible, known := oq.Visible(); visible || !known {
+		t.Fatalf("expected the last result to be kept while the context is lost, got: %t, %t", visible, known)
+	}
+	lost = false
+	glx.contextRestored()
+	if calls := canvas.factory.CallsTo("createQuery"); len(calls) != 4 {
+		t.Fatalf("expected the queries to be created again, got %d createQuery calls", len(calls))
+	}
+	if !oq.Begin() {
+		t.Fatalf("expected a query to begin after restoring")
+	}
+	oq.End()
+}
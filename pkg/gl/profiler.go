@@ -0,0 +1,335 @@
+package gl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TimeElapsed returns the TIME_ELAPSED_EXT query target, which measures the GPU time, in nanoseconds,
+// of the commands issued between Begin and End. Only one query can be active on it at a time.
+func (ext DisjointTimerQuery) TimeElapsed() QueryTarget {
+	return QueryTarget{
+		glx:    ext.glx,
+		target: ext.constants.TIME_ELAPSED_EXT,
+	}
+}
+
+// ProfileScope holds the GPU time spent in a named scope, averaged over the profiler's window.
+type ProfileScope struct {
+	// Path is the names of the scope and its parents, separated by slashes.
+	Path  string
+	Name  string
+	Depth int
+	// Milliseconds is the average time spent per frame, over the frames in which the scope appeared.
+	Milliseconds float64
+	// Frames is the number of frames in the window in which the scope appeared.
+	Frames int
+}
+
+// Profiler measures the GPU time spent in nested, named scopes.
+//
+// Time elapsed queries can not be nested, so the profiler starts a new query at every scope boundary,
+// and attributes its time to all scopes that are open.
+// Results become available a few frames later, and are thrown away if the timer was disjoint.
+//
+// When the context is lost, the frames that were in flight are thrown away and nothing is measured
+// until it is restored, after which the profiler creates new queries.
+type Profiler struct {
+	glx      *Context
+	ext      DisjointTimerQuery
+	window   int
+	free     []QueryObject
+	stack    []string
+	frame    *profilerFrame
+	pending  []*profilerFrame
+	paths    []string
+	seen     map[string]bool
+	history  []map[string]float64
+	frames   []float64
+	disjoint int
+	// measuring is false while the context is lost, or if the extension is missing once it has been restored.
+	measuring  bool
+	deregister []func()
+}
+
+type profilerFrame struct {
+	segments  []profilerSegment
+	discarded bool
+}
+
+type profilerSegment struct {
+	query QueryObject
+	paths []string
+}
+
+// NewProfiler creates a profiler that averages over the last window frames.
+// It returns an error if EXT_disjoint_timer_query_webgl2 is not supported.
+func NewProfiler(glx *Context, window int) (*Profiler, error) {
+	ext, ok := glx.DisjointTimerQuery()
+	if !ok {
+		return nil, fmt.Errorf("EXT_disjoint_timer_query_webgl2 is not supported")
+	}
+	if window < 1 {
+		return nil, fmt.Errorf("invalid profiler window: %d", window)
+	}
+	// Clear the disjoint flag, so it only reports changes after this point.
+	ext.Disjoint()
+	p := &Profiler{
+		glx:       glx,
+		ext:       ext,
+		window:    window,
+		seen:      make(map[string]bool),
+		measuring: true,
+	}
+	p.deregister = []func(){
+		glx.OnLost(p.lost),
+		glx.OnRestore(p.restored),
+	}
+	return p, nil
+}
+
+// lost throws away the queries, which belong to the lost context.
+func (p *Profiler) lost() {
+	p.measuring = false
+	p.free = nil
+	p.pending = nil
+	if p.frame != nil {
+		p.frame = &profilerFrame{discarded: true}
+	}
+}
+
+// restored enables the extension on the restored context, and starts measuring again.
+// The frame in progress, if any, is thrown away once it has been collected.
+func (p *Profiler) restored() {
+	ext, ok := p.glx.DisjointTimerQuery()
+	if !ok {
+		return
+	}
+	ext.Disjoint()
+	p.ext = ext
+	p.measuring = true
+	if p.frame != nil {
+		p.frame = &profilerFrame{discarded: true}
+		p.startSegment()
+	}
+}
+
+// BeginFrame starts profiling a frame.
+func (p *Profiler) BeginFrame() {
+	if p.frame != nil {
+		panic(fmt.Errorf("profiler frame has already begun"))
+	}
+	// Frames that start while the context is lost are not measured.
+	p.frame = &profilerFrame{discarded: !p.measuring}
+	p.startSegment()
+}
+
+// EndFrame stops profiling the frame, and collects the results of earlier frames that have become available.
+func (p *Profiler) EndFrame() {
+	if p.frame == nil {
+		panic(fmt.Errorf("profiler frame has not begun"))
+	}
+	if len(p.stack) > 0 {
+		panic(fmt.Errorf("profiler scope %s was not ended", strings.Join(p.stack, "/")))
+	}
+	p.endSegment()
+	p.pending = append(p.pending, p.frame)
+	p.frame = nil
+	p.collect()
+}
+
+// Begin opens a scope, nested in the currently open scope.
+func (p *Profiler) Begin(name string) {
+	if p.frame == nil {
+		panic(fmt.Errorf("profiler scope %s begun outside of a frame", name))
+	}
+	p.endSegment()
+	p.stack = append(p.stack, name)
+	p.startSegment()
+}
+
+// End closes the innermost open scope.
+func (p *Profiler) End() {
+	if len(p.stack) == 0 {
+		panic(fmt.Errorf("no profiler scope to end"))
+	}
+	p.endSegment()
+	p.stack = p.stack[:len(p.stack)-1]
+	p.startSegment()
+}
+
+// Scope runs f in a scope.
+func (p *Profiler) Scope(name string, f func()) {
+	p.Begin(name)
+	defer p.End()
+	f()
+}
+
+func (p *Profiler) startSegment() {
+	if !p.measuring {
+		return
+	}
+	var query QueryObject
+	if len(p.free) > 0 {
+		query = p.free[len(p.free)-1]
+		p.free = p.free[:len(p.free)-1]
+	} else {
+		query = p.ext.glx.CreateQuery()
+	}
+	var paths []string
+	for i := range p.stack {
+		paths = append(paths, strings.Join(p.stack[:i+1], "/"))
+	}
+	p.frame.segments = append(p.frame.segments, profilerSegment{
+		query: query,
+		paths: paths,
+	})
+	p.ext.TimeElapsed().Begin(query)
+}
+
+func (p *Profiler) endSegment() {
+	if !p.measuring {
+		return
+	}
+	p.ext.TimeElapsed().End()
+}
+
+// collect records the frames whose queries have all completed, in order.
+func (p *Profiler) collect() {
+	if !p.measuring {
+		return
+	}
+	if p.ext.Disjoint() {
+		// The results of all queries that were running are unreliable.
+		p.disjoint++
+		for _, frame := range p.pending {
+			frame.discarded = true
+		}
+	}
+	for len(p.pending) > 0 {
+		frame := p.pending[0]
+		var results []uint
+		for _, segment := range frame.segments {
+			result, available := segment.query.Result()
+			if !available {
+				return
+			}
+			results = append(results, result)
+		}
+		p.pending = p.pending[1:]
+		for _, segment := range frame.segments {
+			p.free = append(p.free, segment.query)
+		}
+		if frame.discarded {
+			continue
+		}
+		total := 0.0
+		totals := make(map[string]float64)
+		for i, segment := range frame.segments {
+			ms := float64(results[i]) / 1e6
+			total += ms
+			for _, path := range segment.paths {
+				totals[path] += ms
+			}
+		}
+		for _, path := range frame.paths() {
+			if !p.seen[path] {
+				p.seen[path] = true
+				p.paths = append(p.paths, path)
+			}
+		}
+		p.frames = append(p.frames, total)
+		p.history = append(p.history, totals)
+		if len(p.frames) > p.window {
+			p.frames = p.frames[1:]
+			p.history = p.history[1:]
+		}
+	}
+}
+
+// Destroy deletes all queries of the profiler, including those of frames whose results have not been collected.
+func (p *Profiler) Destroy() {
+	for _, deregister := range p.deregister {
+		deregister()
+	}
+	p.deregister = nil
+	frames := p.pending
+	if p.frame != nil {
+		p.endSegment()
+		frames = append(frames, p.frame)
+	}
+	for _, frame := range frames {
+		for _, segment := range frame.segments {
+			segment.query.Destroy()
+		}
+	}
+	for _, query := range p.free {
+		query.Destroy()
+	}
+	p.free = nil
+	p.pending = nil
+	p.frame = nil
+	p.stack = nil
+}
+
+// paths returns every scope path in the frame, in the order they were opened.
+func (frame *profilerFrame) paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, segment := range frame.segments {
+		for _, path := range segment.paths {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// FrameMilliseconds returns the average GPU time per frame, over the window.
+func (p *Profiler) FrameMilliseconds() float64 {
+	return average(p.frames)
+}
+
+// DisjointCount returns the number of times results were thrown away because the timer was disjoint.
+func (p *Profiler) DisjointCount() int {
+	return p.disjoint
+}
+
+// Scopes returns the results for every scope that has been seen, in the order they were first opened.
+// Scopes that have not appeared in the last window frames are left out.
+func (p *Profiler) Scopes() []ProfileScope {
+	var scopes []ProfileScope
+	for _, path := range p.paths {
+		var samples []float64
+		for _, totals := range p.history {
+			if ms, ok := totals[path]; ok {
+				samples = append(samples, ms)
+			}
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		names := strings.Split(path, "/")
+		scopes = append(scopes, ProfileScope{
+			Path:         path,
+			Name:         names[len(names)-1],
+			Depth:        len(names) - 1,
+			Milliseconds: average(samples),
+			Frames:       len(samples),
+		})
+	}
+	return scopes
+}
+
+func average(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, sample := range samples {
+		total += sample
+	}
+	return total / float64(len(samples))
+}
@@ -0,0 +1,156 @@
+package gl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+// newFakeTimerCanvas creates a canvas whose queries all measure 1ms, and become available once available is set.
+// While lost is set, queries and parameters return null, like they do on a lost context.
+func newFakeTimerCanvas(available, disjoint, lost *bool) fakeCanvas {
+	return newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		timerQuery := factory.Object()
+		for i, name := range []string{"QUERY_COUNTER_BITS_EXT", "TIME_ELAPSED_EXT", "TIMESTAMP_EXT", "GPU_DISJOINT_EXT"} {
+			timerQuery.Set(name, factory.Number(float64(0x8864+i)))
+		}
+		timerQuery.SetFunc("queryCounterEXT", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Undefined()
+		})
+		glObj.SetFunc("getExtension", func(this driver.Object, args ...driver.Value) driver.Value {
+			if name, _ := args[0].ToString(); name == "EXT_disjoint_timer_query_webgl2" {
+				return timerQuery
+			}
+			return factory.Null()
+		})
+		glObj.SetFunc("getParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if *lost {
+				return factory.Null()
+			}
+			if factory.Equal(args[0], timerQuery.Get("GPU_DISJOINT_EXT")) {
+				rv := *disjoint
+				*disjoint = false
+				return factory.Boolean(rv)
+			}
+			return factory.Undefined()
+		})
+		glObj.SetFunc("getQueryParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			if *lost {
+				return factory.Null()
+			}
+			if factory.Equal(args[1], glObj.Get("QUERY_RESULT_AVAILABLE")) {
+				return factory.Boolean(*available)
+			}
+			return factory.Number(1e6)
+		})
+	})
+}
+
+func profileFrame(p *Profiler) {
+	p.BeginFrame()
+	p.Scope("shadows", func() {
+		p.Scope("cascade", func() {})
+	})
+	p.Scope("main", func() {})
+	p.EndFrame()
+}
+
+func TestProfiler(t *testing.T) {
+	available, disjoint, lost := false, false, false
+	canvas := newFakeTimerCanvas(&available, &disjoint, &lost)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	p, err := NewProfiler(glx, 2)
+	if err != nil {
+		t.Fatalf("creating profiler: %v", err)
+	}
+
+	profileFrame(p)
+	if scopes := p.Scopes(); len(scopes) != 0 {
+		t.Fatalf("expected no results before queries are available, got: %v", scopes)
+	}
+	available = true
+	profileFrame(p)
+	want := []ProfileScope{
+		{Path: "shadows", Name: "shadows", Depth: 0, Milliseconds: 3, Frames: 2},
+		{Path: "shadows/cascade", Name: "cascade", Depth: 1, Milliseconds: 1, Frames: 2},
+		{Path: "main", Name: "main", Depth: 0, Milliseconds: 1, Frames: 2},
+	}
+	if got := p.Scopes(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected scopes %v, got: %v", want, got)
+	}
+	if ms := p.FrameMilliseconds(); ms != 7 {
+		t.Fatalf("expected 7ms per frame, got: %v", ms)
+	}
+	// Queries are reused once their results have been read.
+	if calls := canvas.factory.CallsTo("createQuery"); len(calls) != 14 {
+		t.Fatalf("expected 14 queries to be created, got: %d", len(calls))
+	}
+	profileFrame(p)
+	if calls := canvas.factory.CallsTo("createQuery"); len(calls) != 14 {
+		t.Fatalf("expected queries to be reused, got %d created", len(calls))
+	}
+
+	disjoint = true
+	profileFrame(p)
+	if p.DisjointCount() != 1 {
+		t.Fatalf("expected 1 disjoint event, got: %d", p.DisjointCount())
+	}
+	if scopes := p.Scopes(); len(scopes) != 3 || scopes[0].Frames != 2 {
+		t.Fatalf("expected the disjoint frame to be discarded, got: %v", scopes)
+	}
+
+	available, disjoint = false, false
+	profileFrame(p)
+	p.Destroy()
+	created, deleted := canvas.factory.CallsTo("createQuery"), canvas.factory.CallsTo("deleteQuery")
+	if len(deleted) != len(created) {
+		t.Fatalf("expected all %d queries to be deleted, got: %d", len(created), len(deleted))
+	}
+}
+
+func TestProfilerContextLoss(t *testing.T) {
+	available, disjoint, lost := true, false, false
+	canvas := newFakeTimerCanvas(&available, &disjoint, &lost)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	p, err := NewProfiler(glx, 2)
+	if err != nil {
+		t.Fatalf("creating profiler: %v", err)
+	}
+	defer p.Destroy()
+	profileFrame(p)
+
+	// The context is lost in the middle of a frame, and restored in the middle of the next.
+	p.BeginFrame()
+	p.Begin("shadows")
+	lost = true
+	glx.contextLost()
+	p.End()
+	p.Scope("main", func() {})
+	p.EndFrame()
+	profileFrame(p)
+	p.BeginFrame()
+	lost = false
+	glx.contextRestored()
+	p.Scope("main", func() {})
+	p.EndFrame()
+	if calls := canvas.factory.CallsTo("getExtension"); len(calls) != 2 {
+		t.Fatalf("expected the extension to be enabled again after restoring, got %d getExtension calls", len(calls))
+	}
+	created := len(canvas.factory.CallsTo("createQuery"))
+
+	profileFrame(p)
+	profileFrame(p)
+	if calls := canvas.factory.CallsTo("createQuery"); len(calls) == created {
+		t.Fatalf("expected new queries to be created after restoring")
+	}
+	want := []ProfileScope{
+		{Path: "shadows", Name: "shadows", Depth: 0, Milliseconds: 3, Frames: 2},
+		{Path: "shadows/cascade", Name: "cascade", Depth: 1, Milliseconds: 1, Frames: 2},
+		{Path: "main", Name: "main", Depth: 0, Milliseconds: 1, Frames: 2},
+	}
+	if got := p.Scopes(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the frames after restoring to be measured, got: %v", got)
+	}
+}
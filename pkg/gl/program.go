@@ -0,0 +1,219 @@
+package gl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProgramConfig describes a program for BuildProgram.
+type ProgramConfig struct {
+	VertexSource   string
+	FragmentSource string
+	// FeedbackVaryings are the vertex shader outputs captured by transform feedback.
+	FeedbackVaryings []string
+	// FeedbackInterleaved captures the varyings into a single buffer, instead of one buffer each.
+	FeedbackInterleaved bool
+}
+
+// BuildProgram compiles the shaders and links them into a program.
+// If compiling or linking fails, it returns a *ProgramError.
+func (glx *Context) BuildProgram(cfg ProgramConfig) (ProgramObject, error) {
+	sources := map[ShaderType]string{
+		VertexShader:   cfg.VertexSource,
+		FragmentShader: cfg.FragmentSource,
+	}
+	program := glx.CreateProgram()
+	var shaders []ShaderObject
+	for _, stage := range []ShaderType{VertexShader, FragmentShader} {
+		shader := glx.CreateShader(stage)
+		// The shader is only deleted once the program is.
+		defer shader.Destroy()
+		shader.Source(sources[stage])
+		shader.Compile()
+		program.Attach(shader)
+		shaders = append(shaders, shader)
+	}
+	var diagnostics []ShaderDiagnostic
+	for _, shader := range shaders {
+		if !shader.CompilationSuccess() {
+			diagnostics = append(diagnostics, parseInfoLog(shader.typ, shader.InfoLog())...)
+		}
+	}
+	if len(diagnostics) > 0 {
+		program.Destroy()
+		return ProgramObject{}, &ProgramError{
+			Op:          "compiling",
+			Diagnostics: diagnostics,
+			Sources:     sources,
+		}
+	}
+	if len(cfg.FeedbackVaryings) > 0 {
+		program.TransformFeedbackVaryings(cfg.FeedbackInterleaved, cfg.FeedbackVaryings...)
+	}
+	program.Link()
+	if !program.LinkSuccess() {
+		log := program.InfoLog()
+		program.Destroy()
+		return ProgramObject{}, &ProgramError{
+			Op:          "linking",
+			Diagnostics: parseInfoLog(0, log),
+			Sources:     sources,
+		}
+	}
+	return program, nil
+}
+
+// ShaderDiagnostic is a single message from a shader compiler or linker info log.
+type ShaderDiagnostic struct {
+	// Stage is the shader the message is about; it is zero for link messages.
+	Stage ShaderType
+	// SourceString is the source string number the line is in, which can be set with a #line directive.
+	SourceString int
+	// File is the name of the file the line is in, if the source was put together from several files.
+	File string
+	// Line and Column are 1-based, and zero if the log did not include them.
+	Line   int
+	Column int
+	// Severity is "error" or "warning".
+	Severity string
+	Message  string
+}
+
+func (d ShaderDiagnostic) String() string {
+	s := stageName(d.Stage)
+	if d.File != "" {
+		s = d.File
+	}
+	if d.Line > 0 {
+		s += ":" + strconv.Itoa(d.Line)
+		if d.Column > 0 {
+			s += ":" + strconv.Itoa(d.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s", s, d.Severity, d.Message)
+}
+
+func stageName(stage ShaderType) string {
+	switch stage {
+	case VertexShader:
+		return "vertex shader"
+	case FragmentShader:
+		return "fragment shader"
+	default:
+		return "program"
+	}
+}
+
+// ProgramError is returned by BuildProgram when compiling or linking fails.
+type ProgramError struct {
+	// Op is "compiling" or "linking".
+	Op          string
+	Diagnostics []ShaderDiagnostic
+	// Sources holds the source of each shader, used to show where the diagnostics point.
+	Sources map[ShaderType]string
+	// Files holds the source of each file named by a diagnostic; it is used instead of Sources for those.
+	Files map[string]string
+}
+
+// Error renders the diagnostics with two lines of source context around each.
+func (err *ProgramError) Error() string {
+	return err.Render(2)
+}
+
+// Render renders the diagnostics, each followed by the source lines it points to,
+// with contextLines lines before and after it.
+func (err *ProgramError) Render(contextLines int) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s program failed", err.Op)
+	if len(err.Diagnostics) == 0 {
+		b.WriteString(" without diagnostics")
+	}
+	for _, d := range err.Diagnostics {
+		b.WriteString("\n")
+		b.WriteString(d.String())
+		source, ok := err.Sources[d.Stage]
+		if d.File != "" {
+			source, ok = err.Files[d.File]
+		}
+		if !ok || d.Line == 0 {
+			continue
+		}
+		lines := strings.Split(source, "\n")
+		if d.Line > len(lines) {
+			continue
+		}
+		first, last := d.Line-contextLines, d.Line+contextLines
+		if first < 1 {
+			first = 1
+		}
+		if last > len(lines) {
+			last = len(lines)
+		}
+		width := len(strconv.Itoa(last))
+		for n := first; n <= last; n++ {
+			marker := " "
+			if n == d.Line {
+				marker = ">"
+			}
+			fmt.Fprintf(b, "\n%s %*d | %s", marker, width, n, lines[n-1])
+			if n == d.Line && d.Column > 0 {
+				fmt.Fprintf(b, "\n  %*s | %s^", width, "", strings.Repeat(" ", d.Column-1))
+			}
+		}
+	}
+	return b.String()
+}
+
+var infoLogFormats = []struct {
+	re *regexp.Regexp
+	// The indices of the submatches; zero if the format lacks it.
+	severity, source, line, column, message int
+}{
+	// ANGLE, used by most browsers: "ERROR: 0:12: 'x' : undeclared identifier"
+	{re: regexp.MustCompile(`^(ERROR|WARNING): (\d+):(\d+): (.*)$`), severity: 1, source: 2, line: 3, message: 4},
+	// Mesa: "0:12(5): error: `x' undeclared"
+	{re: regexp.MustCompile(`^(\d+):(\d+)\((\d+)\): (error|warning): (.*)$`), source: 1, line: 2, column: 3, severity: 4, message: 5},
+	// NVIDIA: "0(12) : error C1008: undefined variable "x""
+	{re: regexp.MustCompile(`^(\d+)\((\d+)\) : (error|warning) \w+: (.*)$`), source: 1, line: 2, severity: 3, message: 4},
+	// ANGLE link errors: "ERROR: Varying 'x' not declared"
+	{re: regexp.MustCompile(`^(ERROR|WARNING): (.*)$`), severity: 1, message: 2},
+}
+
+// parseInfoLog splits an info log into diagnostics.
+// Lines in an unknown format become errors without a line number.
+func parseInfoLog(stage ShaderType, log string) []ShaderDiagnostic {
+	var diagnostics []ShaderDiagnostic
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(strings.TrimRight(line, "\x00"))
+		if line == "" {
+			continue
+		}
+		d := ShaderDiagnostic{
+			Stage:    stage,
+			Severity: "error",
+			Message:  line,
+		}
+		for _, format := range infoLogFormats {
+			m := format.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.Severity = strings.ToLower(m[format.severity])
+			d.Message = m[format.message]
+			if format.source > 0 {
+				d.SourceString, _ = strconv.Atoi(m[format.source])
+			}
+			if format.line > 0 {
+				d.Line, _ = strconv.Atoi(m[format.line])
+			}
+			if format.column > 0 {
+				d.Column, _ = strconv.Atoi(m[format.column])
+			}
+			break
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
@@ -0,0 +1,110 @@
+package gl
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestParseInfoLog(t *testing.T) {
+	log := "ERROR: 0:3: 'colour' : undeclared identifier\n" +
+		"0:4(12): warning: unused variable\n" +
+		"0(5) : error C1008: undefined variable \"x\"\n" +
+		"ERROR: 2 compilation errors.  No code generated.\n" +
+		"something else\n\x00"
+	want := []ShaderDiagnostic{
+		{Stage: FragmentShader, Line: 3, Severity: "error", Message: "'colour' : undeclared identifier"},
+		{Stage: FragmentShader, Line: 4, Column: 12, Severity: "warning", Message: "unused variable"},
+		{Stage: FragmentShader, Line: 5, Severity: "error", Message: "undefined variable \"x\""},
+		{Stage: FragmentShader, Severity: "error", Message: "2 compilation errors.  No code generated."},
+		{Stage: FragmentShader, Severity: "error", Message: "something else"},
+	}
+	if got := parseInfoLog(FragmentShader, log); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got: %+v", want, got)
+	}
+}
+
+func TestProgramErrorRender(t *testing.T) {
+	err := &ProgramError{
+		Op: "compiling",
+		Diagnostics: []ShaderDiagnostic{
+			{Stage: VertexShader, Line: 2, Column: 3, Severity: "error", Message: "bad"},
+		},
+		Sources: map[ShaderType]string{
+			VertexShader: "one\ntwo\nthree\nfour",
+		},
+	}
+	want := "compiling program failed\n" +
+		"vertex shader:2:3: error: bad\n" +
+		"  1 | one\n" +
+		"> 2 | two\n" +
+		"    |   ^\n" +
+		"  3 | three"
+	if got := err.Render(1); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestBuildProgram(t *testing.T) {
+	linked := true
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		sources := make(map[driver.Value]string)
+		glObj.SetFunc("shaderSource", func(this driver.Object, args ...driver.Value) driver.Value {
+			sources[args[0]], _ = args[1].ToString()
+			return factory.Undefined()
+		})
+		glObj.SetFunc("getShaderParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Boolean(!strings.Contains(sources[args[0]], "broken"))
+		})
+		glObj.SetFunc("getShaderInfoLog", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.String("ERROR: 0:2: 'broken' : syntax error\n")
+		})
+		glObj.SetFunc("getProgramParameter", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.Boolean(linked)
+		})
+		glObj.SetFunc("getProgramInfoLog", func(this driver.Object, args ...driver.Value) driver.Value {
+			return factory.String("ERROR: Varying 'Color' not declared\n")
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+
+	cfg := ProgramConfig{
+		VertexSource:     "#version 300 es\nvoid main() {}",
+		FragmentSource:   "#version 300 es\nbroken\nvoid main() {}",
+		FeedbackVaryings: []string{"Size"},
+	}
+	_, err := glx.BuildProgram(cfg)
+	var programErr *ProgramError
+	if !errors.As(err, &programErr) {
+		t.Fatalf("expected a ProgramError, got: %v", err)
+	}
+	want := []ShaderDiagnostic{{Stage: FragmentShader, Line: 2, Severity: "error", Message: "'broken' : syntax error"}}
+	if programErr.Op != "compiling" || !reflect.DeepEqual(programErr.Diagnostics, want) {
+		t.Fatalf("expected compile diagnostics %+v, got: %+v", want, programErr)
+	}
+	if !strings.Contains(err.Error(), "> 2 | broken") {
+		t.Fatalf("expected the error to point at the broken line, got:\n%v", err)
+	}
+	if calls := canvas.factory.CallsTo("linkProgram"); len(calls) != 0 {
+		t.Fatalf("expected no link after a compile error, got %d calls", len(calls))
+	}
+
+	cfg.FragmentSource = "#version 300 es\nvoid main() {}"
+	linked = false
+	_, err = glx.BuildProgram(cfg)
+	if !errors.As(err, &programErr) || programErr.Op != "linking" || len(programErr.Diagnostics) != 1 || programErr.Diagnostics[0].Stage != 0 {
+		t.Fatalf("expected a link error, got: %v", err)
+	}
+
+	linked = true
+	if _, err := glx.BuildProgram(cfg); err != nil {
+		t.Fatalf("building program: %v", err)
+	}
+	if calls := canvas.factory.CallsTo("transformFeedbackVaryings"); len(calls) != 2 {
+		t.Fatalf("expected feedback varyings to be set before each link, got %d calls", len(calls))
+	}
+}
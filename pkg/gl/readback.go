@@ -0,0 +1,201 @@
+package gl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// packAlignment is the default PACK_ALIGNMENT; every row read by readPixels starts at a multiple of it.
+const packAlignment = 4
+
+//go:generate stringer -type=PixelFormat
+type PixelFormat int
+
+const (
+	PixelRGBA PixelFormat = iota + 1
+	PixelRed
+	PixelRG
+	// PixelRGBAInteger reads the unnormalized values of an integer color buffer.
+	PixelRGBAInteger
+	PixelRedInteger
+	PixelRGInteger
+)
+
+func (format PixelFormat) glValue(glx *Context) driver.Value {
+	switch format {
+	case PixelRGBA:
+		return glx.constants.RGBA
+	case PixelRed:
+		return glx.constants.RED
+	case PixelRG:
+		return glx.constants.RG
+	case PixelRGBAInteger:
+		return glx.constants.RGBA_INTEGER
+	case PixelRedInteger:
+		return glx.constants.RED_INTEGER
+	case PixelRGInteger:
+		return glx.constants.RG_INTEGER
+	default:
+		panic(fmt.Errorf("invalid pixel format: %v", format))
+	}
+}
+
+func (format PixelFormat) components() int {
+	switch format {
+	case PixelRed, PixelRedInteger:
+		return 1
+	case PixelRG, PixelRGInteger:
+		return 2
+	case PixelRGBA, PixelRGBAInteger:
+		return 4
+	default:
+		panic(fmt.Errorf("invalid pixel format: %v", format))
+	}
+}
+
+// pixelLayout returns the size of a row of pixels without padding, and the size of the data readPixels writes.
+func pixelLayout(w, h int, format PixelFormat, typ Type) (rowSize, size int) {
+	rowSize = w * format.components() * typ.glSize()
+	if h == 0 {
+		return rowSize, 0
+	}
+	stride := (rowSize + packAlignment - 1) / packAlignment * packAlignment
+	return rowSize, stride*(h-1) + rowSize
+}
+
+// ReadPixelsToBuffer reads pixels from the bound framebuffer into the buffer bound to the PixelPack target,
+// starting at offset bytes. Each row is padded to a multiple of 4 bytes.
+// The call does not wait for the GPU; the buffer contents can be read once a fence issued after it is signaled.
+func (target FramebufferTarget) ReadPixelsToBuffer(x, y, w, h int, format PixelFormat, typ Type, offset int) {
+	glx := target.glx
+	glx.constants.ReadPixels(
+		glx.factory.Number(float64(x)),
+		glx.factory.Number(float64(y)),
+		glx.factory.Number(float64(w)),
+		glx.factory.Number(float64(h)),
+		format.glValue(glx),
+		glx.typeConverter.ToJs(typ),
+		glx.factory.Number(float64(offset)),
+	)
+}
+
+// PixelReadback is a pixel read that completes asynchronously.
+type PixelReadback struct {
+	glx     *Context
+	buffer  BufferObject
+	sync    SyncObject
+	width   int
+	height  int
+	rowSize int
+	size    int
+	data    []byte
+}
+
+// ReadPixelsAsync starts reading pixels from the bound framebuffer, without stalling the pipeline.
+// Every pixel has a component of type typ per channel in format;
+// the format and type must be supported by the color buffer, for example Float for a floating point buffer.
+// The readback must be destroyed once its data has been retrieved.
+// If the context is lost before the pixels have arrived, Result and Wait return an error,
+// and the pixels have to be read again once the context has been restored.
+func (target FramebufferTarget) ReadPixelsAsync(x, y, w, h int, format PixelFormat, typ Type) *PixelReadback {
+	glx := target.glx
+	rowSize, size := pixelLayout(w, h, format, typ)
+	buffer := glx.CreateBuffer()
+	pack := glx.Targets().PixelPack()
+	pack.BindBuffer(buffer)
+	glx.constants.BufferData(
+		glx.constants.PIXEL_PACK_BUFFER,
+		glx.factory.Number(float64(size)),
+		glx.constants.STREAM_READ,
+	)
+	target.ReadPixelsToBuffer(x, y, w, h, format, typ, 0)
+	pack.UnbindBuffer()
+	sync := glx.FenceSync()
+	glx.Flush()
+	return &PixelReadback{
+		glx:     glx,
+		buffer:  buffer,
+		sync:    sync,
+		width:   w,
+		height:  h,
+		rowSize: rowSize,
+		size:    size,
+	}
+}
+
+// Ready returns whether the pixels have arrived, without blocking.
+// It returns false if the readback failed, which Result reports.
+func (r *PixelReadback) Ready() bool {
+	return r.data != nil || r.sync.Status() == SyncSignaled
+}
+
+// Result returns the pixels if they have arrived, without blocking.
+// Rows are tightly packed, starting at the bottom.
+// It returns an error if the pixels will never arrive, for example because the context was lost.
+func (r *PixelReadback) Result() (data []byte, ok bool, err error) {
+	if r.data != nil {
+		return r.data, true, nil
+	}
+	switch r.sync.Status() {
+	case SyncSignaled:
+		return r.fetch(), true, nil
+	case SyncFailed:
+		return nil, false, fmt.Errorf("waiting for readback: waiting for sync object failed")
+	default:
+		return nil, false, nil
+	}
+}
+
+// Wait polls until the pixels have arrived, and returns them.
+func (r *PixelReadback) Wait(ctx context.Context) ([]byte, error) {
+	if r.data == nil {
+		if _, err := r.sync.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for readback: %w", err)
+		}
+	}
+	return r.fetch(), nil
+}
+
+// fetch copies the pixels out of the pack buffer, and removes the row padding.
+func (r *PixelReadback) fetch() []byte {
+	if r.data != nil {
+		return r.data
+	}
+	glx := r.glx
+	data := make([]byte, r.rowSize*r.height)
+	if r.size == 0 {
+		r.data = data
+		return data
+	}
+	pack := glx.Targets().PixelPack()
+	pack.BindBuffer(r.buffer)
+	jsBuffer, jsArray := glx.staging.get(r.size)
+	glx.constants.GetBufferSubData(
+		glx.constants.PIXEL_PACK_BUFFER,
+		glx.factory.Number(0),
+		jsArray,
+		glx.factory.Number(0), // dstOffset
+		glx.factory.Number(float64(r.size)),
+	)
+	pack.UnbindBuffer()
+	padded := make([]byte, r.size)
+	if num := jsBuffer.Get(padded); num != r.size {
+		panic(fmt.Errorf("expected jsBuffer.Get to return %d, got %d", r.size, num))
+	}
+	stride := (r.rowSize + packAlignment - 1) / packAlignment * packAlignment
+	for row := 0; row < r.height; row++ {
+		copy(data[row*r.rowSize:(row+1)*r.rowSize], padded[row*stride:])
+	}
+	r.data = data
+	return data
+}
+
+func (r *PixelReadback) Destroy() {
+	r.sync.Destroy()
+	// If the context was lost, the buffer was deleted with it.
+	if r.glx.valid(r.sync.generation) {
+		r.buffer.Destroy()
+	}
+}
@@ -0,0 +1,108 @@
+package gl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestPixelReadback(t *testing.T) {
+	polls := 0
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("clientWaitSync", func(this driver.Object, args ...driver.Value) driver.Value {
+			polls++
+			if polls < 2 {
+				return glObj.Get("TIMEOUT_EXPIRED")
+			}
+			return glObj.Get("ALREADY_SIGNALED")
+		})
+		glObj.SetFunc("getBufferSubData", func(this driver.Object, args ...driver.Value) driver.Value {
+			array, _ := args[2].ToObject()
+			size, _ := args[4].ToFloat64()
+			for i := 0; i < int(size); i++ {
+				array.SetIndex(i, factory.Number(float64(i)))
+			}
+			return factory.Undefined()
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	readback := glx.Targets().Framebuffer().ReadPixelsAsync(1, 2, 3, 2, PixelRed, UnsignedByte)
+	defer readback.Destroy()
+
+	calls := canvas.factory.CallsTo("readPixels")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 readPixels call, got: %d", len(calls))
+	}
+	if !canvas.factory.Equal(calls[0].Args[4], glx.constants.RED) || !canvas.factory.Equal(calls[0].Args[5], glx.constants.UNSIGNED_BYTE) {
+		t.Fatalf("unexpected readPixels arguments: %v", calls[0].Args)
+	}
+	calls = canvas.factory.CallsTo("bufferData")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 bufferData call, got: %d", len(calls))
+	}
+	// Two rows of 3 bytes, the first padded to 4.
+	if size, _ := calls[0].Args[1].ToFloat64(); size != 7 {
+		t.Fatalf("expected the pack buffer to be 7 bytes, got: %v", size)
+	}
+
+	if _, ok, err := readback.Result(); ok || err != nil {
+		t.Fatalf("expected the readback not to be ready, got: %t %v", ok, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	data, err := readback.Wait(ctx)
+	if err != nil {
+		t.Fatalf("waiting for readback: %v", err)
+	}
+	if want := []byte{0, 1, 2, 4, 5, 6}; !bytes.Equal(data, want) {
+		t.Fatalf("expected %v, got: %v", want, data)
+	}
+	if data, ok, err := readback.Result(); !ok || err != nil || len(data) != 6 {
+		t.Fatalf("expected the result to be available after waiting, got: %v %t %v", data, ok, err)
+	}
+	if calls := canvas.factory.CallsTo("getBufferSubData"); len(calls) != 1 {
+		t.Fatalf("expected the buffer to be read once, got: %d", len(calls))
+	}
+}
+
+func TestPixelReadbackFailed(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("clientWaitSync", func(this driver.Object, args ...driver.Value) driver.Value {
+			return glObj.Get("WAIT_FAILED")
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	readback := glx.Targets().Framebuffer().ReadPixelsAsync(0, 0, 1, 1, PixelRGBA, UnsignedByte)
+	defer readback.Destroy()
+	if readback.Ready() {
+		t.Fatalf("expected a failed readback not to be ready")
+	}
+	if data, ok, err := readback.Result(); ok || err == nil {
+		t.Fatalf("expected the result to report the failure, got: %v %t %v", data, ok, err)
+	}
+}
+
+func TestPixelLayout(t *testing.T) {
+	for _, test := range []struct {
+		w, h    int
+		format  PixelFormat
+		typ     Type
+		rowSize int
+		size    int
+	}{
+		{w: 5, h: 3, format: PixelRGBA, typ: UnsignedByte, rowSize: 20, size: 60},
+		{w: 5, h: 3, format: PixelRed, typ: UnsignedByte, rowSize: 5, size: 21},
+		{w: 5, h: 3, format: PixelRGBAInteger, typ: UnsignedInt, rowSize: 80, size: 240},
+		{w: 3, h: 1, format: PixelRG, typ: Float, rowSize: 24, size: 24},
+		{w: 3, h: 0, format: PixelRed, typ: UnsignedByte, rowSize: 3, size: 0},
+	} {
+		rowSize, size := pixelLayout(test.w, test.h, test.format, test.typ)
+		if rowSize != test.rowSize || size != test.size {
+			t.Fatalf("%dx%d %v %v: expected %d/%d, got: %d/%d", test.w, test.h, test.format, test.typ, test.rowSize, test.size, rowSize, size)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package gl
+
+import (
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+const minStagingSize = 256
+
+// StagingStats describes the usage of a Context's staging buffers,
+// which are used to move data between Go and GL.
+type StagingStats struct {
+	// Requests is the number of times a staging buffer was needed.
+	Requests int
+	// Allocations is the number of JavaScript buffers that were allocated to serve the requests.
+	Allocations int
+	// AllocatedBytes is the combined size of all allocated buffers.
+	AllocatedBytes int
+}
+
+// stagingPool keeps one grow-only staging buffer per power-of-two size class.
+// Staging buffers are only used for the duration of a single GL call,
+// so a request can always reuse the buffer of its size class.
+type stagingPool struct {
+	factory driver.Factory
+	classes map[int]stagingBuffer
+	stats   StagingStats
+	// encoded holds data encoded in Go before it is copied into a staging buffer.
+	encoded []byte
+}
+
+type stagingBuffer struct {
+	buffer driver.Buffer
+	array  driver.Object
+}
+
+func newStagingPool(factory driver.Factory) *stagingPool {
+	return &stagingPool{
+		factory: factory,
+		classes: make(map[int]stagingBuffer),
+	}
+}
+
+// get returns a staging buffer and its Uint8Array of at least size bytes.
+// Both may be larger than size, so the GL call must be given the size explicitly.
+func (pool *stagingPool) get(size int) (buffer driver.Buffer, array driver.Object) {
+	pool.stats.Requests++
+	class := minStagingSize
+	for class < size {
+		class *= 2
+	}
+	staging, ok := pool.classes[class]
+	if !ok {
+		staging.buffer = pool.factory.Buffer(class)
+		staging.array = staging.buffer.AsUint8Array()
+		pool.classes[class] = staging
+		pool.stats.Allocations++
+		pool.stats.AllocatedBytes += class
+	}
+	return staging.buffer, staging.array
+}
+
+// encode returns a Go byte slice of exactly size bytes, to encode data into before copying it into a staging buffer.
+// It is reused by the next call.
+func (pool *stagingPool) encode(size int) []byte {
+	if cap(pool.encoded) < size {
+		pool.encoded = make([]byte, size)
+	}
+	return pool.encoded[:size]
+}
+
+func (glx *Context) StagingStats() StagingStats {
+	return glx.staging.stats
+}
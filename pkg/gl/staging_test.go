@@ -0,0 +1,25 @@
+package gl
+
+import (
+	"testing"
+)
+
+func TestStagingPool(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	target := glx.Targets().Array()
+	for i := 0; i < 10; i++ {
+		target.BufferData(make([]byte, 100), Dynamic, Draw)
+	}
+	target.BufferData(make([]byte, 1000), Dynamic, Draw)
+	target.BufferData(make([]byte, 200), Dynamic, Draw)
+
+	want := StagingStats{
+		Requests:       12,
+		Allocations:    2,
+		AllocatedBytes: minStagingSize + 1024,
+	}
+	if got := glx.StagingStats(); got != want {
+		t.Fatalf("expected staging stats %+v, got: %+v", want, got)
+	}
+}
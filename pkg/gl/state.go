@@ -0,0 +1,195 @@
+package gl
+
+import (
+	"fmt"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// StateCacheStats counts the state changing calls made through a Context.
+type StateCacheStats struct {
+	// Issued is the number of calls that were passed on to GL.
+	Issued int
+	// Skipped is the number of calls that were left out, because they would not have changed the state.
+	Skipped int
+}
+
+// stateCache shadows the GL state that is changed most often, so calls that would not change it can be skipped.
+// State that has not been set through the cache yet is unknown, and the first call to set it is always issued.
+// Buffer, texture and enable flag state is keyed by the number of the GL constant.
+type stateCache struct {
+	factory     driver.Factory
+	disabled    bool
+	stats       StateCacheStats
+	program     driver.Value
+	vao         driver.Value
+	framebuffer driver.Value
+	buffers     map[float64]driver.Value
+	activeUnit  int
+	textures    map[textureBinding]driver.Value
+	enabled     map[float64]bool
+}
+
+type textureBinding struct {
+	unit   int
+	target float64
+}
+
+func newStateCache(factory driver.Factory, disabled bool) *stateCache {
+	cache := &stateCache{
+		factory:  factory,
+		disabled: disabled,
+	}
+	cache.reset()
+	return cache
+}
+
+// reset forgets all state, for when it was changed without going through the cache.
+func (cache *stateCache) reset() {
+	cache.program = nil
+	cache.vao = nil
+	cache.framebuffer = nil
+	cache.buffers = make(map[float64]driver.Value)
+	cache.activeUnit = -1
+	cache.textures = make(map[textureBinding]driver.Value)
+	cache.enabled = make(map[float64]bool)
+}
+
+// change sets the state in slot to value, and reports whether the call setting it must be issued.
+func (cache *stateCache) change(slot *driver.Value, value driver.Value) bool {
+	if !cache.disabled && *slot != nil && cache.factory.Equal(*slot, value) {
+		cache.stats.Skipped++
+		return false
+	}
+	*slot = value
+	cache.stats.Issued++
+	return true
+}
+
+func (glx *Context) useProgram(program driver.Value) {
+	if glx.state.change(&glx.state.program, program) {
+		glx.constants.UseProgram(program)
+	}
+}
+
+func (glx *Context) bindVertexArray(vao driver.Value) {
+	if glx.state.change(&glx.state.vao, vao) {
+		glx.constants.BindVertexArray(vao)
+		// The element array buffer binding is part of the vertex array object.
+		if key, ok := glx.constants.ELEMENT_ARRAY_BUFFER.ToFloat64(); ok {
+			delete(glx.state.buffers, key)
+		}
+	}
+}
+
+func (glx *Context) bindFramebuffer(fbo driver.Value) {
+	if glx.state.change(&glx.state.framebuffer, fbo) {
+		glx.constants.BindFramebuffer(glx.constants.FRAMEBUFFER, fbo)
+	}
+}
+
+func (glx *Context) bindBuffer(target, buffer driver.Value) {
+	state := glx.state
+	key, ok := target.ToFloat64()
+	if !ok {
+		state.stats.Issued++
+		glx.constants.BindBuffer(target, buffer)
+		return
+	}
+	slot := state.buffers[key]
+	if state.change(&slot, buffer) {
+		state.buffers[key] = slot
+		glx.constants.BindBuffer(target, buffer)
+	}
+}
+
+// bindBufferBase binds the buffer to an indexed binding point, which is not cached,
+// and to the generic binding point of the target.
+func (glx *Context) bindBufferBase(target driver.Value, index int, buffer driver.Value) {
+	glx.constants.BindBufferBase(target, glx.factory.Number(float64(index)), buffer)
+	if key, ok := target.ToFloat64(); ok {
+		glx.state.buffers[key] = buffer
+	}
+}
+
+func (glx *Context) activeTexture(unit int) {
+	state := glx.state
+	if !state.disabled && state.activeUnit == unit {
+		state.stats.Skipped++
+		return
+	}
+	state.activeUnit = unit
+	state.stats.Issued++
+	fTexture0, ok := glx.constants.TEXTURE0.ToFloat64()
+	if !ok {
+		panic(fmt.Errorf("expected TEXTURE0 to be a number: %T", glx.constants.TEXTURE0))
+	}
+	glx.constants.ActiveTexture(glx.factory.Number(fTexture0 + float64(unit)))
+}
+
+func (glx *Context) bindTexture(target, texture driver.Value) {
+	state := glx.state
+	key, ok := target.ToFloat64()
+	if !ok || state.activeUnit == -1 {
+		// The binding can not be cached without knowing the unit it is bound to.
+		state.stats.Issued++
+		glx.constants.BindTexture(target, texture)
+		return
+	}
+	binding := textureBinding{unit: state.activeUnit, target: key}
+	slot := state.textures[binding]
+	if state.change(&slot, texture) {
+		state.textures[binding] = slot
+		glx.constants.BindTexture(target, texture)
+	}
+}
+
+func (glx *Context) setEnabled(capability driver.Value, enabled bool) {
+	state := glx.state
+	key, ok := capability.ToFloat64()
+	if ok && !state.disabled {
+		if current, known := state.enabled[key]; known && current == enabled {
+			state.stats.Skipped++
+			return
+		}
+	}
+	if ok {
+		state.enabled[key] = enabled
+	}
+	state.stats.Issued++
+	if enabled {
+		glx.constants.Enable(capability)
+	} else {
+		glx.constants.Disable(capability)
+	}
+}
+
+// forget makes the cache forget a deleted object, since deleting a bound object unbinds it.
+func (cache *stateCache) forget(value driver.Value) {
+	for _, slot := range []*driver.Value{&cache.vao, &cache.framebuffer} {
+		if *slot != nil && cache.factory.Equal(*slot, value) {
+			*slot = nil
+		}
+	}
+	for key, buffer := range cache.buffers {
+		if cache.factory.Equal(buffer, value) {
+			delete(cache.buffers, key)
+		}
+	}
+	for key, texture := range cache.textures {
+		if cache.factory.Equal(texture, value) {
+			delete(cache.textures, key)
+		}
+	}
+}
+
+// StateCacheStats returns the number of state changing calls that were issued and skipped.
+func (glx *Context) StateCacheStats() StateCacheStats {
+	return glx.state.stats
+}
+
+// ResetStateCache makes the Context forget the GL state it has shadowed.
+// It must be called after changing GL state without going through the Context.
+func (glx *Context) ResetStateCache() {
+	glx.state.reset()
+}
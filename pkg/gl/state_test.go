@@ -0,0 +1,85 @@
+package gl
+
+import (
+	"testing"
+)
+
+func TestStateCacheSkipsRedundantCalls(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	buffer := glx.CreateBuffer()
+	program := glx.CreateProgram()
+	texture := glx.CreateTexture()
+	for i := 0; i < 3; i++ {
+		glx.Targets().Array().BindBuffer(buffer)
+		glx.UseProgram(program)
+		glx.Targets().ActiveTextureUnit(1)
+		glx.Targets().Texture2D().Bind(texture)
+		glx.Features().Blend(true)
+	}
+	for _, name := range []string{"bindBuffer", "useProgram", "activeTexture", "bindTexture", "enable"} {
+		if calls := canvas.factory.CallsTo(name); len(calls) != 1 {
+			t.Fatalf("expected 1 %s call, got: %d", name, len(calls))
+		}
+	}
+	if want, got := (StateCacheStats{Issued: 5, Skipped: 10}), glx.StateCacheStats(); got != want {
+		t.Fatalf("expected stats %+v, got: %+v", want, got)
+	}
+
+	glx.Targets().ActiveTextureUnit(0)
+	glx.Targets().Texture2D().Bind(texture)
+	if calls := canvas.factory.CallsTo("bindTexture"); len(calls) != 2 {
+		t.Fatalf("expected binding on another unit to be issued, got %d bindTexture calls", len(calls))
+	}
+	glx.Features().Blend(false)
+	if calls := canvas.factory.CallsTo("disable"); len(calls) != 1 {
+		t.Fatalf("expected 1 disable call, got: %d", len(calls))
+	}
+
+	buffer.Destroy()
+	glx.Targets().Array().UnbindBuffer()
+	glx.ResetStateCache()
+	glx.UseProgram(program)
+	if calls := canvas.factory.CallsTo("bindBuffer"); len(calls) != 2 {
+		t.Fatalf("expected unbinding a destroyed buffer to be issued, got %d bindBuffer calls", len(calls))
+	}
+	if calls := canvas.factory.CallsTo("useProgram"); len(calls) != 2 {
+		t.Fatalf("expected useProgram to be issued after a reset, got %d calls", len(calls))
+	}
+}
+
+func TestStateCacheVertexArray(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{})
+	vao := glx.CreateVertexArray()
+	indices := glx.CreateBuffer()
+	glx.Targets().ElementArray().BindBuffer(indices)
+	glx.BindVertexArray(vao)
+	glx.BindVertexArray(vao)
+	glx.Targets().ElementArray().BindBuffer(indices)
+	if calls := canvas.factory.CallsTo("bindVertexArray"); len(calls) != 1 {
+		t.Fatalf("expected 1 bindVertexArray call, got: %d", len(calls))
+	}
+	if calls := canvas.factory.CallsTo("bindBuffer"); len(calls) != 2 {
+		t.Fatalf("expected the element array binding to be issued after binding a vertex array, got %d bindBuffer calls", len(calls))
+	}
+}
+
+func TestStateCacheDisabled(t *testing.T) {
+	canvas := newFakeCanvas(nil)
+	glx := newTestContext(t, canvas, ContextConfig{DisableStateCache: true})
+	buffer := glx.CreateBuffer()
+	for i := 0; i < 3; i++ {
+		glx.Targets().Array().BindBuffer(buffer)
+		glx.Features().CullFace(true, BackFace)
+	}
+	if calls := canvas.factory.CallsTo("bindBuffer"); len(calls) != 3 {
+		t.Fatalf("expected 3 bindBuffer calls, got: %d", len(calls))
+	}
+	if calls := canvas.factory.CallsTo("enable"); len(calls) != 3 {
+		t.Fatalf("expected 3 enable calls, got: %d", len(calls))
+	}
+	if want, got := (StateCacheStats{Issued: 6}), glx.StateCacheStats(); got != want {
+		t.Fatalf("expected stats %+v, got: %+v", want, got)
+	}
+}
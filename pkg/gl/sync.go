@@ -0,0 +1,99 @@
+package gl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+//go:generate stringer -type=SyncStatus
+type SyncStatus int
+
+const (
+	// SyncUnsignaled means the commands before the fence have not completed yet.
+	SyncUnsignaled SyncStatus = iota + 1
+	// SyncSignaled means the commands before the fence have completed.
+	SyncSignaled
+	// SyncFailed means the fence can no longer be waited on, for example because the context was lost.
+	SyncFailed
+)
+
+// SyncObject is a fence, which is signaled once all GL commands issued before it have completed.
+type SyncObject struct {
+	glx        *Context
+	value      driver.Value
+	generation int
+}
+
+// FenceSync inserts a fence after the commands issued so far.
+func (glx *Context) FenceSync() SyncObject {
+	value := glx.constants.FenceSync(
+		glx.constants.SYNC_GPU_COMMANDS_COMPLETE,
+		glx.factory.Number(0),
+	)
+	return SyncObject{
+		glx:        glx,
+		value:      value,
+		generation: glx.generation(),
+	}
+}
+
+// Status returns whether the fence has been signaled, without blocking.
+// Browsers only update the status between tasks, so it will not change while Go keeps the event loop busy.
+func (sync SyncObject) Status() SyncStatus {
+	glx := sync.glx
+	if !glx.valid(sync.generation) {
+		// The fence belongs to a lost context.
+		return SyncFailed
+	}
+	rv := glx.constants.ClientWaitSync(sync.value, glx.factory.Number(0), glx.factory.Number(0))
+	switch {
+	case glx.factory.Equal(rv, glx.constants.ALREADY_SIGNALED), glx.factory.Equal(rv, glx.constants.CONDITION_SATISFIED):
+		return SyncSignaled
+	case glx.factory.Equal(rv, glx.constants.TIMEOUT_EXPIRED):
+		return SyncUnsignaled
+	case glx.factory.Equal(rv, glx.constants.WAIT_FAILED):
+		return SyncFailed
+	default:
+		panic(fmt.Errorf("ClientWaitSync returned an unknown value: %v", rv))
+	}
+}
+
+// Wait polls until the fence has been signaled, and returns how long it waited.
+func (sync SyncObject) Wait(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	glx := sync.glx
+	glx.Flush()
+	for {
+		switch sync.Status() {
+		case SyncSignaled:
+			return time.Now().Sub(start), nil
+		case SyncFailed:
+			return time.Now().Sub(start), fmt.Errorf("waiting for sync object failed")
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Millisecond / 10):
+		}
+	}
+}
+
+// ServerWait makes the GPU wait for the fence before executing further commands; it does not block.
+func (sync SyncObject) ServerWait() {
+	glx := sync.glx
+	if !glx.valid(sync.generation) {
+		return
+	}
+	glx.constants.WaitSync(sync.value, glx.factory.Number(0), glx.constants.TIMEOUT_IGNORED)
+}
+
+func (sync SyncObject) Destroy() {
+	glx := sync.glx
+	if !glx.valid(sync.generation) {
+		return
+	}
+	glx.constants.DeleteSync(sync.value)
+}
@@ -0,0 +1,80 @@
+package gl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestSyncObject(t *testing.T) {
+	polls := 0
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("clientWaitSync", func(this driver.Object, args ...driver.Value) driver.Value {
+			polls++
+			if polls < 3 {
+				return glObj.Get("TIMEOUT_EXPIRED")
+			}
+			return glObj.Get("CONDITION_SATISFIED")
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	sync := glx.FenceSync()
+	if status := sync.Status(); status != SyncUnsignaled {
+		t.Fatalf("expected %v, got: %v", SyncUnsignaled, status)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := sync.Wait(ctx); err != nil {
+		t.Fatalf("waiting for sync: %v", err)
+	}
+	if polls != 3 {
+		t.Fatalf("expected 3 polls, got: %d", polls)
+	}
+	if calls := canvas.factory.CallsTo("flush"); len(calls) != 1 {
+		t.Fatalf("expected Wait to flush, got %d calls", len(calls))
+	}
+	sync.Destroy()
+	calls := canvas.factory.CallsTo("deleteSync")
+	if len(calls) != 1 || !canvas.factory.Equal(calls[0].Args[0], sync.value) {
+		t.Fatalf("expected the sync object to be deleted, got: %v", calls)
+	}
+}
+
+func TestSyncObjectCanceled(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("clientWaitSync", func(this driver.Object, args ...driver.Value) driver.Value {
+			return glObj.Get("TIMEOUT_EXPIRED")
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := glx.FenceSync().Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestSyncObjectContextLost(t *testing.T) {
+	canvas := newFakeCanvas(func(factory *fakejs.Factory, glObj *fakejs.Object) {
+		glObj.SetFunc("clientWaitSync", func(this driver.Object, args ...driver.Value) driver.Value {
+			return glObj.Get("TIMEOUT_EXPIRED")
+		})
+	})
+	glx := newTestContext(t, canvas, ContextConfig{})
+	sync := glx.FenceSync()
+	glx.contextLost()
+	glx.contextRestored()
+	if status := sync.Status(); status != SyncFailed {
+		t.Fatalf("expected a fence of the lost context to have failed, got: %v", status)
+	}
+	sync.Destroy()
+	if calls := canvas.factory.CallsTo("deleteSync"); len(calls) != 0 {
+		t.Fatalf("expected a fence of the lost context not to be deleted, got %d calls", len(calls))
+	}
+	if status := glx.FenceSync().Status(); status != SyncUnsignaled {
+		t.Fatalf("expected a new fence to be unsignaled, got: %v", status)
+	}
+}
@@ -36,12 +36,12 @@ type ArrayTarget struct {
 
 func (target ArrayTarget) BindBuffer(buffer BufferObject) {
 	glx := target.glx
-	glx.constants.BindBuffer(target.which, buffer.value)
+	glx.bindBuffer(target.which, buffer.value)
 }
 
 func (target ArrayTarget) UnbindBuffer() {
 	glx := target.glx
-	glx.constants.BindBuffer(target.which, glx.factory.Null())
+	glx.bindBuffer(target.which, glx.factory.Null())
 }
 
 func (target ArrayTarget) BufferData(data []byte, accessUsage AccessUsage, modificationUsage ModificationUsage) {
@@ -50,11 +50,21 @@ func (target ArrayTarget) BufferData(data []byte, accessUsage AccessUsage, modif
 }
 
 func bufferData(glx *Context, target driver.Value, data []byte, accessUsage AccessUsage, modificationUsage ModificationUsage) {
-	jsBuffer := glx.factory.Buffer(len(data))
-	jsBuffer.Put(data)
-	jsByteArray := jsBuffer.AsUint8Array()
 	glUsage := combineUsage(glx, accessUsage, modificationUsage)
-	glx.constants.BufferData(target, jsByteArray, glUsage)
+	if len(data) == 0 {
+		// A length of 0 would upload the entire staging buffer.
+		glx.constants.BufferData(target, glx.factory.Number(0), glUsage)
+		return
+	}
+	jsBuffer, jsByteArray := glx.staging.get(len(data))
+	jsBuffer.Put(data)
+	glx.constants.BufferData(
+		target,
+		jsByteArray,
+		glUsage,
+		glx.factory.Number(0), // srcOffset
+		glx.factory.Number(float64(len(data))),
+	)
 }
 
 func combineUsage(glx *Context, accessUsage AccessUsage, modificationUsage ModificationUsage) driver.Value {
@@ -126,6 +136,17 @@ func (targets Targets) ElementArray() ArrayTarget {
 	}
 }
 
+// PixelPack returns the PIXEL_PACK_BUFFER target.
+// ReadPixelsToBuffer reads into the buffer bound to it, and ReadPixels can not be used while one is bound.
+func (targets Targets) PixelPack() ArrayTarget {
+	glx := targets.glx
+	return ArrayTarget{
+		glx:     glx,
+		which:   glx.constants.PIXEL_PACK_BUFFER,
+		indexed: false,
+	}
+}
+
 type RenderbufferTarget struct {
 	glx *Context
 }
@@ -204,13 +225,7 @@ func (targets Targets) Texture2D() Texture2DTarget {
 
 func (targets Targets) ActiveTextureUnit(unit int) {
 	glx := targets.glx
-	fTexture0, ok := glx.constants.TEXTURE0.ToFloat64()
-	if !ok {
-		panic(fmt.Errorf("expected TEXTURE0 to be a number: %T", glx.constants.TEXTURE0))
-	}
-	t0 := int(fTexture0)
-	jsTextureUnit := glx.factory.Number(float64(t0 + unit))
-	glx.constants.ActiveTexture(jsTextureUnit)
+	glx.activeTexture(unit)
 }
 
 type UniformTarget struct {
@@ -225,38 +240,24 @@ func (targets Targets) Uniform() UniformTarget {
 
 func (target UniformTarget) Bind(buffer BufferObject) {
 	glx := target.glx
-	glx.constants.BindBuffer(
-		glx.constants.UNIFORM_BUFFER,
-		buffer.value,
-	)
+	glx.bindBuffer(glx.constants.UNIFORM_BUFFER, buffer.value)
 }
 
 func (target UniformTarget) Unbind() {
 	glx := target.glx
-	glx.constants.BindBuffer(
-		glx.constants.UNIFORM_BUFFER,
-		glx.factory.Null(),
-	)
+	glx.bindBuffer(glx.constants.UNIFORM_BUFFER, glx.factory.Null())
 }
 
 // also Binds
 func (target UniformTarget) BindBase(index int, buffer BufferObject) {
 	glx := target.glx
-	glx.constants.BindBufferBase(
-		glx.constants.UNIFORM_BUFFER,
-		glx.factory.Number(float64(index)),
-		buffer.value,
-	)
+	glx.bindBufferBase(glx.constants.UNIFORM_BUFFER, index, buffer.value)
 }
 
 // also Unbinds
 func (target UniformTarget) UnbindBase(index int) {
 	glx := target.glx
-	glx.constants.BindBufferBase(
-		glx.constants.UNIFORM_BUFFER,
-		glx.factory.Number(float64(index)),
-		glx.factory.Null(),
-	)
+	glx.bindBufferBase(glx.constants.UNIFORM_BUFFER, index, glx.factory.Null())
 }
 
 func (target UniformTarget) BufferData(data []byte, accessUsage AccessUsage, modificationUsage ModificationUsage) {
@@ -277,30 +278,22 @@ func (targets Targets) TransformFeedback() TransformFeedbackTarget {
 
 func (target TransformFeedbackTarget) Bind(buffer BufferObject) {
 	glx := target.glx
-	glx.constants.BindBuffer(glx.constants.TRANSFORM_FEEDBACK_BUFFER, buffer.value)
+	glx.bindBuffer(glx.constants.TRANSFORM_FEEDBACK_BUFFER, buffer.value)
 }
 
 func (target TransformFeedbackTarget) Unbind() {
 	glx := target.glx
-	glx.constants.BindBuffer(glx.constants.TRANSFORM_FEEDBACK_BUFFER, glx.factory.Null())
+	glx.bindBuffer(glx.constants.TRANSFORM_FEEDBACK_BUFFER, glx.factory.Null())
 }
 
 func (target TransformFeedbackTarget) BindBase(index int, buffer BufferObject) {
 	glx := target.glx
-	glx.constants.BindBufferBase(
-		glx.constants.TRANSFORM_FEEDBACK_BUFFER,
-		glx.factory.Number(float64(index)),
-		buffer.value,
-	)
+	glx.bindBufferBase(glx.constants.TRANSFORM_FEEDBACK_BUFFER, index, buffer.value)
 }
 
 func (target TransformFeedbackTarget) UnbindBase(index int) {
 	glx := target.glx
-	glx.constants.BindBufferBase(
-		glx.constants.TRANSFORM_FEEDBACK_BUFFER,
-		glx.factory.Number(float64(index)),
-		glx.factory.Null(),
-	)
+	glx.bindBufferBase(glx.constants.TRANSFORM_FEEDBACK_BUFFER, index, glx.factory.Null())
 }
 
 func (target TransformFeedbackTarget) Alloc(size int, accessUsage AccessUsage, modificationUsage ModificationUsage) {
@@ -313,12 +306,13 @@ func (target TransformFeedbackTarget) Contents(data []byte) int {
 	if len(data) == 0 {
 		return 0
 	}
-	jsBuffer := glx.factory.Buffer(len(data))
-	jsArray := jsBuffer.AsUint8Array()
+	jsBuffer, jsArray := glx.staging.get(len(data))
 	glx.constants.GetBufferSubData(
 		glx.constants.TRANSFORM_FEEDBACK_BUFFER,
 		glx.factory.Number(float64(0)),
 		jsArray,
+		glx.factory.Number(0), // dstOffset
+		glx.factory.Number(float64(len(data))),
 	)
 	return jsBuffer.Get(data)
 }
@@ -329,3 +323,21 @@ func (targets Targets) QueryTransformFeedbackPrimitivesWritten() QueryTarget {
 		target: targets.glx.constants.TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN,
 	}
 }
+
+// QueryAnySamplesPassed returns the occlusion query target, whose result is 1 if any samples
+// passed the depth and stencil tests between Begin and End, and 0 otherwise.
+func (targets Targets) QueryAnySamplesPassed() QueryTarget {
+	return QueryTarget{
+		glx:    targets.glx,
+		target: targets.glx.constants.ANY_SAMPLES_PASSED,
+	}
+}
+
+// QueryAnySamplesPassedConservative is like QueryAnySamplesPassed, but may report samples passing
+// when none did, in exchange for being faster.
+func (targets Targets) QueryAnySamplesPassedConservative() QueryTarget {
+	return QueryTarget{
+		glx:    targets.glx,
+		target: targets.glx.constants.ANY_SAMPLES_PASSED_CONSERVATIVE,
+	}
+}
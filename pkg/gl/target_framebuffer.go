@@ -12,18 +12,12 @@ type FramebufferTarget struct {
 
 func (target FramebufferTarget) Bind(fbo FramebufferObject) {
 	glx := target.glx
-	glx.constants.BindFramebuffer(
-		glx.constants.FRAMEBUFFER,
-		fbo.value,
-	)
+	glx.bindFramebuffer(fbo.value)
 }
 
 func (target FramebufferTarget) Unbind() {
 	glx := target.glx
-	glx.constants.BindFramebuffer(
-		glx.constants.FRAMEBUFFER,
-		glx.factory.Null(),
-	)
+	glx.bindFramebuffer(glx.factory.Null())
 }
 
 func (target FramebufferTarget) AttachRenderbuffer(attachmentType RenderbufferType, rbo RenderbufferObject) {
@@ -62,8 +56,7 @@ func (target FramebufferTarget) IsComplete() bool {
 func (target FramebufferTarget) ReadPixels(x, y, w, h int) []byte {
 	glx := target.glx
 	pixelDataSize := w * h * 4
-	jsBuffer := glx.factory.Buffer(pixelDataSize)
-	jsArray := jsBuffer.AsUint8Array()
+	jsBuffer, jsArray := glx.staging.get(pixelDataSize)
 	glx.constants.ReadPixels(
 		glx.factory.Number(float64(x)),
 		glx.factory.Number(float64(y)),
@@ -14,23 +14,18 @@ type Texture2DTarget struct {
 
 func (target Texture2DTarget) Bind(texture TextureObject) {
 	glx := target.glx
-	glx.constants.BindTexture(
-		glx.constants.TEXTURE_2D,
-		texture.value,
-	)
+	glx.bindTexture(glx.constants.TEXTURE_2D, texture.value)
 }
 
 func (target Texture2DTarget) Unbind() {
 	glx := target.glx
-	glx.constants.BindTexture(
-		glx.constants.TEXTURE_2D,
-		glx.factory.Null(),
-	)
+	glx.bindTexture(glx.constants.TEXTURE_2D, glx.factory.Null())
 }
 
 func (to TextureObject) Destroy() {
 	glx := to.glx
 	glx.constants.DeleteTexture(to.value)
+	glx.state.forget(to.value)
 }
 
 type TextureFilter int
@@ -118,7 +113,7 @@ func (target Texture2DTarget) Allocate(width, height, level int) {
 func (target Texture2DTarget) SubImage(x, y, level int, img image.Image) {
 	glx := target.glx
 	imageWidth, imageHeight, imageData := imageToBytes(img, img.Bounds())
-	jsImageData := glx.factory.Buffer(len(imageData))
+	jsImageData, jsImageArray := glx.staging.get(len(imageData))
 	jsImageData.Put(imageData)
 	glx.constants.TexSubImage2D(
 		glx.constants.TEXTURE_2D,
@@ -129,7 +124,7 @@ func (target Texture2DTarget) SubImage(x, y, level int, img image.Image) {
 		glx.factory.Number(float64(imageHeight)),
 		glx.constants.RGBA,
 		glx.constants.UNSIGNED_BYTE,
-		jsImageData.AsUint8Array(),
+		jsImageArray,
 		glx.factory.Number(0), // offset
 	)
 }
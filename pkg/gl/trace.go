@@ -0,0 +1,376 @@
+package gl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+// traceObjectKey is the property under which the tracer stores the id of an object returned by GL.
+const traceObjectKey = "warpTraceObject"
+
+// TraceSink receives every GL call made by a Context created with ContextConfig.Trace.
+type TraceSink interface {
+	TraceCall(call TraceCall)
+}
+
+// TraceSinkFunc adapts a function to a TraceSink.
+type TraceSinkFunc func(call TraceCall)
+
+func (f TraceSinkFunc) TraceCall(call TraceCall) {
+	f(call)
+}
+
+// TraceCall is a single traced GL call.
+// When the Context has a command buffer, recorded calls are traced when they are recorded,
+// so their duration does not include the work done by GL.
+type TraceCall struct {
+	Function string        `json:"function"`
+	Args     []TraceValue  `json:"args"`
+	Return   TraceValue    `json:"return"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+func (call TraceCall) String() string {
+	var args []string
+	for _, arg := range call.Args {
+		args = append(args, arg.String())
+	}
+	s := fmt.Sprintf("%s(%s)", call.Function, strings.Join(args, ", "))
+	if call.Return.Type != "undefined" {
+		s += " = " + call.Return.String()
+	}
+	return s
+}
+
+// TraceValue is a copy of a value passed to or returned from GL.
+// Type is the JavaScript type, except for typed arrays and arrays, which are copied in full.
+// Objects returned by GL get an id, so that later calls can refer to them.
+type TraceValue struct {
+	Type     string       `json:"type"`
+	Boolean  bool         `json:"boolean,omitempty"`
+	Number   float64      `json:"number,omitempty"`
+	Text     string       `json:"text,omitempty"`
+	Object   int          `json:"object,omitempty"`
+	Array    string       `json:"array,omitempty"`
+	Bytes    []byte       `json:"bytes,omitempty"`
+	Elements []TraceValue `json:"elements,omitempty"`
+}
+
+func (v TraceValue) String() string {
+	switch v.Type {
+	case "boolean":
+		return strconv.FormatBool(v.Boolean)
+	case "number":
+		return strconv.FormatFloat(v.Number, 'g', -1, 64)
+	case "string":
+		return strconv.Quote(v.Text)
+	case "object":
+		if v.Object == 0 {
+			return "object"
+		}
+		return "#" + strconv.Itoa(v.Object)
+	case "typedarray":
+		return fmt.Sprintf("%s(%d bytes)", v.Array, len(v.Bytes))
+	case "array":
+		var elements []string
+		for _, element := range v.Elements {
+			elements = append(elements, element.String())
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	default:
+		return v.Type
+	}
+}
+
+// TracePrinter returns a TraceSink that writes every call to w on a line of its own.
+func TracePrinter(w io.Writer) TraceSink {
+	return TraceSinkFunc(func(call TraceCall) {
+		_, _ = fmt.Fprintf(w, "[TRACE] %v (%v)\n", call, call.Duration)
+	})
+}
+
+// tracer converts the arguments and return values of GL calls for a TraceSink.
+type tracer struct {
+	factory    driver.Factory
+	sink       TraceSink
+	lastObject int
+	array      driver.Function
+	typed      map[driver.ArrayType]driver.Function
+}
+
+func newTracer(factory driver.Factory, sink TraceSink) *tracer {
+	tr := &tracer{
+		factory: factory,
+		sink:    sink,
+		typed:   make(map[driver.ArrayType]driver.Function),
+	}
+	global := factory.Global()
+	tr.array, _ = global.Get("Array").ToFunction()
+	for t := driver.Int8Array; t <= driver.Float64Array; t++ {
+		if constructor, ok := global.Get(t.String()).ToFunction(); ok {
+			tr.typed[t] = constructor
+		}
+	}
+	return tr
+}
+
+func (tr *tracer) wrap(functionName string, f func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
+	return func(args ...driver.Value) driver.Value {
+		call := TraceCall{
+			Function: functionName,
+		}
+		for _, arg := range args {
+			call.Args = append(call.Args, tr.value(arg, false))
+		}
+		call.Start = time.Now()
+		rv := f(args...)
+		call.Duration = time.Since(call.Start)
+		call.Return = tr.value(rv, true)
+		tr.sink.TraceCall(call)
+		return rv
+	}
+}
+
+// value copies a value.
+// If returned is set, objects that do not have an id yet are given one.
+func (tr *tracer) value(v driver.Value, returned bool) TraceValue {
+	if v == nil || v.IsNull() {
+		return TraceValue{Type: "null"}
+	}
+	if v.IsUndefined() {
+		return TraceValue{Type: "undefined"}
+	}
+	if b, ok := v.ToBoolean(); ok {
+		return TraceValue{Type: "boolean", Boolean: b}
+	}
+	if f, ok := v.ToFloat64(); ok {
+		return TraceValue{Type: "number", Number: f}
+	}
+	if s, ok := v.ToString(); ok {
+		return TraceValue{Type: "string", Text: s}
+	}
+	obj, ok := v.ToObject()
+	if !ok {
+		return TraceValue{Type: v.TypeOf()}
+	}
+	if id, ok := obj.Get(traceObjectKey).ToFloat64(); ok {
+		return TraceValue{Type: "object", Object: int(id)}
+	}
+	for t, constructor := range tr.typed {
+		if obj.InstanceOf(constructor) {
+			return TraceValue{Type: "typedarray", Array: t.String(), Bytes: tr.bytes(obj)}
+		}
+	}
+	if tr.array != nil && obj.InstanceOf(tr.array) {
+		elements := driver.IndexableToSlice(tr.factory, obj)
+		traced := TraceValue{Type: "array", Elements: make([]TraceValue, 0, len(elements))}
+		for _, element := range elements {
+			traced.Elements = append(traced.Elements, tr.value(element, returned))
+		}
+		return traced
+	}
+	if !returned {
+		return TraceValue{Type: "object"}
+	}
+	tr.lastObject++
+	obj.Set(traceObjectKey, tr.factory.Number(float64(tr.lastObject)))
+	return TraceValue{Type: "object", Object: tr.lastObject}
+}
+
+// bytes copies the bytes viewed by a typed array.
+func (tr *tracer) bytes(obj driver.Object) []byte {
+	byteLength, _ := obj.Get("byteLength").ToFloat64()
+	byteOffset, _ := obj.Get("byteOffset").ToFloat64()
+	arrayBuffer := obj.Get("buffer")
+	view := tr.typed[driver.Uint8Array].New(arrayBuffer, tr.factory.Number(byteOffset), tr.factory.Number(byteLength))
+	data := make([]byte, int(byteLength))
+	copyBuffer := tr.factory.Buffer(len(data))
+	if set := driver.Bind(copyBuffer.AsUint8Array(), "set"); set != nil {
+		set(view)
+		copyBuffer.Get(data)
+		return data
+	}
+	for i := range data {
+		b, _ := view.Index(i).ToFloat64()
+		data[i] = byte(b)
+	}
+	return data
+}
+
+// Trace is a capture of GL calls, split into frames.
+type Trace struct {
+	Frames []TraceFrame `json:"frames"`
+}
+
+// TraceFrame holds the GL calls of a single frame.
+type TraceFrame struct {
+	Calls []TraceCall `json:"calls"`
+}
+
+// Save writes the trace as JSON.
+func (trace Trace) Save(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(trace); err != nil {
+		return fmt.Errorf("encoding trace: %w", err)
+	}
+	return nil
+}
+
+// LoadTrace reads a trace written by Trace.Save.
+func LoadTrace(r io.Reader) (Trace, error) {
+	var trace Trace
+	if err := json.NewDecoder(r).Decode(&trace); err != nil {
+		return Trace{}, fmt.Errorf("decoding trace: %w", err)
+	}
+	return trace, nil
+}
+
+// TraceRecorder is a TraceSink that captures calls frame by frame.
+type TraceRecorder struct {
+	frames  []TraceFrame
+	current TraceFrame
+}
+
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+func (recorder *TraceRecorder) TraceCall(call TraceCall) {
+	recorder.current.Calls = append(recorder.current.Calls, call)
+}
+
+// EndFrame ends the current frame; it should be called once all calls for a frame have been made.
+func (recorder *TraceRecorder) EndFrame() {
+	recorder.frames = append(recorder.frames, recorder.current)
+	recorder.current = TraceFrame{}
+}
+
+// Trace returns all frames that have been ended.
+func (recorder *TraceRecorder) Trace() Trace {
+	return Trace{
+		Frames: append([]TraceFrame(nil), recorder.frames...),
+	}
+}
+
+// TraceReplayer makes the calls of a trace on another Context.
+// Objects are mapped from the ones returned in the trace to the ones returned while replaying,
+// so the frames of a trace must be replayed in order, starting with the first.
+type TraceReplayer struct {
+	glx       *Context
+	functions map[string]func(args ...driver.Value) driver.Value
+	objects   map[int]driver.Value
+}
+
+func NewTraceReplayer(glx *Context) *TraceReplayer {
+	functions := make(map[string]func(args ...driver.Value) driver.Value)
+	v := reflect.ValueOf(glx.constants)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if function, ok := v.Field(i).Interface().(func(args ...driver.Value) driver.Value); ok {
+			field := t.Field(i)
+			functions[strings.ToLower(field.Name[:1])+field.Name[1:]] = function
+		}
+	}
+	return &TraceReplayer{
+		glx:       glx,
+		functions: functions,
+		objects:   make(map[int]driver.Value),
+	}
+}
+
+// Replay makes all calls in the frame, and returns an error for the first one that fails.
+// The calls bypass the state cache of the Context, so it is reset.
+func (replayer *TraceReplayer) Replay(frame TraceFrame) error {
+	defer replayer.glx.state.reset()
+	for i, call := range frame.Calls {
+		if err := replayer.call(call); err != nil {
+			return fmt.Errorf("replaying call %d (%v): %w", i, call, err)
+		}
+	}
+	return nil
+}
+
+// ReplayAll replays every frame of the trace.
+func (replayer *TraceReplayer) ReplayAll(trace Trace) error {
+	for i, frame := range trace.Frames {
+		if err := replayer.Replay(frame); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (replayer *TraceReplayer) call(call TraceCall) (err error) {
+	function, ok := replayer.functions[call.Function]
+	if !ok {
+		return fmt.Errorf("unknown function")
+	}
+	var args []driver.Value
+	for _, arg := range call.Args {
+		value, err := replayer.value(arg)
+		if err != nil {
+			return err
+		}
+		args = append(args, value)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("call failed: %v", r)
+		}
+	}()
+	rv := function(args...)
+	if call.Return.Type == "object" && call.Return.Object != 0 {
+		replayer.objects[call.Return.Object] = rv
+	}
+	return nil
+}
+
+func (replayer *TraceReplayer) value(v TraceValue) (driver.Value, error) {
+	factory := replayer.glx.factory
+	switch v.Type {
+	case "undefined":
+		return factory.Undefined(), nil
+	case "null":
+		return factory.Null(), nil
+	case "boolean":
+		return factory.Boolean(v.Boolean), nil
+	case "number":
+		return factory.Number(v.Number), nil
+	case "string":
+		return factory.String(v.Text), nil
+	case "object":
+		obj, ok := replayer.objects[v.Object]
+		if !ok {
+			return nil, fmt.Errorf("object %v was not returned by an earlier call", v)
+		}
+		return obj, nil
+	case "typedarray":
+		for t := driver.Int8Array; t <= driver.Float64Array; t++ {
+			if t.String() == v.Array {
+				buffer := factory.Buffer(len(v.Bytes))
+				buffer.Put(v.Bytes)
+				return buffer.As(t), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown typed array: %s", v.Array)
+	case "array":
+		var elements []driver.Value
+		for _, element := range v.Elements {
+			value, err := replayer.value(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, value)
+		}
+		return factory.Array(elements...), nil
+	default:
+		return nil, fmt.Errorf("can not replay a value of type %s", v.Type)
+	}
+}
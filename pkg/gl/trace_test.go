@@ -0,0 +1,86 @@
+package gl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PieterD/warp/pkg/driver/fakejs"
+)
+
+func TestTraceReplay(t *testing.T) {
+	recorder := NewTraceRecorder()
+	var printed bytes.Buffer
+	sinks := TraceSinkFunc(func(call TraceCall) {
+		recorder.TraceCall(call)
+		TracePrinter(&printed).TraceCall(call)
+	})
+	glx := newTestContext(t, newFakeCanvas(nil), ContextConfig{Trace: sinks})
+	buffer := glx.CreateBuffer()
+	glx.Targets().Array().BindBuffer(buffer)
+	recorder.EndFrame()
+	glx.Targets().Array().BufferData([]byte{1, 2, 3}, Static, Draw)
+	glx.Viewport(0, 0, 640, 480)
+	recorder.EndFrame()
+	glx.Viewport(0, 0, 1, 1)
+
+	trace := recorder.Trace()
+	if len(trace.Frames) != 2 {
+		t.Fatalf("expected 2 frames, got: %d", len(trace.Frames))
+	}
+	arrayBuffer, _ := glx.constants.ARRAY_BUFFER.ToFloat64()
+	if want, got := fmt.Sprintf("bindBuffer(%v, #1)", arrayBuffer), trace.Frames[0].Calls[1].String(); got != want {
+		t.Fatalf("expected call %s, got: %s", want, got)
+	}
+	if !strings.Contains(printed.String(), "[TRACE] viewport(0, 0, 1, 1)") {
+		t.Fatalf("expected the printer to print the last viewport call, got:\n%s", printed.String())
+	}
+
+	var saved bytes.Buffer
+	if err := trace.Save(&saved); err != nil {
+		t.Fatalf("saving trace: %v", err)
+	}
+	loaded, err := LoadTrace(&saved)
+	if err != nil {
+		t.Fatalf("loading trace: %v", err)
+	}
+
+	canvas := newFakeCanvas(nil)
+	replayer := NewTraceReplayer(newTestContext(t, canvas, ContextConfig{}))
+	if err := replayer.ReplayAll(loaded); err != nil {
+		t.Fatalf("replaying trace: %v", err)
+	}
+	created := canvas.factory.CallsTo("createBuffer")
+	bound := canvas.factory.CallsTo("bindBuffer")
+	if len(created) != 1 || len(bound) != 1 {
+		t.Fatalf("expected 1 createBuffer and bindBuffer call, got: %d and %d", len(created), len(bound))
+	}
+	if !canvas.factory.Equal(bound[0].Args[1], created[0].Return) {
+		t.Fatalf("expected the replayed bindBuffer to bind the replayed buffer")
+	}
+	uploaded := canvas.factory.CallsTo("bufferData")
+	if len(uploaded) != 1 {
+		t.Fatalf("expected 1 bufferData call, got: %d", len(uploaded))
+	}
+	data, _ := fakejs.Bytes(uploaded[0].Args[1])
+	if want := []byte{1, 2, 3}; !reflect.DeepEqual(data[:3], want) {
+		t.Fatalf("expected replayed data %v, got: %v", want, data[:3])
+	}
+	if viewports := canvas.factory.CallsTo("viewport"); len(viewports) != 1 {
+		t.Fatalf("expected only the ended frames to be replayed, got %d viewport calls", len(viewports))
+	}
+}
+
+func TestTraceReplayUnknownObject(t *testing.T) {
+	trace := TraceFrame{Calls: []TraceCall{{
+		Function: "bindBuffer",
+		Args:     []TraceValue{{Type: "number", Number: 1}, {Type: "object", Object: 7}},
+		Return:   TraceValue{Type: "undefined"},
+	}}}
+	err := NewTraceReplayer(newTestContext(t, newFakeCanvas(nil), ContextConfig{})).Replay(trace)
+	if err == nil || !strings.Contains(err.Error(), "object #7 was not returned by an earlier call") {
+		t.Fatalf("expected an unknown object error, got: %v", err)
+	}
+}
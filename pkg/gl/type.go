@@ -24,6 +24,17 @@ const (
 	UnsignedShort
 	Int
 	UnsignedInt
+	IVec2
+	IVec3
+	IVec4
+	UVec2
+	UVec3
+	UVec4
+	Bool
+	Sampler2D
+	Sampler3D
+	SamplerCube
+	Sampler2DArray
 )
 
 func (t Type) glSize() int {
@@ -32,13 +43,13 @@ func (t Type) glSize() int {
 		return 1
 	case Short, UnsignedShort:
 		return 2
-	case Int, UnsignedInt, Float:
+	case Int, UnsignedInt, Float, Bool:
 		return 4
-	case Vec2:
+	case Vec2, IVec2, UVec2:
 		return 2 * 4
-	case Vec3:
+	case Vec3, IVec3, UVec3:
 		return 3 * 4
-	case Vec4:
+	case Vec4, IVec4, UVec4:
 		return 4 * 4
 	case Mat2:
 		return 2 * 2 * 4
@@ -53,8 +64,10 @@ func (t Type) glSize() int {
 
 func (t Type) GLSL() string {
 	switch t {
-	case Vec2, Vec3, Vec4, Mat2, Mat3, Mat4:
+	case Vec2, Vec3, Vec4, Mat2, Mat3, Mat4, IVec2, IVec3, IVec4, UVec2, UVec3, UVec4, Bool:
 		return strings.ToLower(t.String())
+	case Sampler2D, Sampler3D, SamplerCube, Sampler2DArray:
+		return "sampler" + strings.TrimPrefix(t.String(), "Sampler")
 	default:
 		panic(fmt.Errorf("unimplemented %s.GLSL", t.String()))
 	}
@@ -83,22 +96,38 @@ type typeConverter struct {
 
 func newTypeConverter(constants glConstants) *typeConverter {
 	jsConstants := map[Type]driver.Value{
-		Float:         constants.FLOAT,
-		Vec2:          constants.FLOAT_VEC2,
-		Vec3:          constants.FLOAT_VEC3,
-		Vec4:          constants.FLOAT_VEC4,
-		Mat2:          constants.FLOAT_MAT2,
-		Mat3:          constants.FLOAT_MAT3,
-		Mat4:          constants.FLOAT_MAT4,
-		Byte:          constants.BYTE,
-		UnsignedByte:  constants.UNSIGNED_BYTE,
-		Short:         constants.SHORT,
-		UnsignedShort: constants.UNSIGNED_SHORT,
-		Int:           constants.INT,
-		UnsignedInt:   constants.UNSIGNED_INT,
+		Float:          constants.FLOAT,
+		Vec2:           constants.FLOAT_VEC2,
+		Vec3:           constants.FLOAT_VEC3,
+		Vec4:           constants.FLOAT_VEC4,
+		Mat2:           constants.FLOAT_MAT2,
+		Mat3:           constants.FLOAT_MAT3,
+		Mat4:           constants.FLOAT_MAT4,
+		Byte:           constants.BYTE,
+		UnsignedByte:   constants.UNSIGNED_BYTE,
+		Short:          constants.SHORT,
+		UnsignedShort:  constants.UNSIGNED_SHORT,
+		Int:            constants.INT,
+		UnsignedInt:    constants.UNSIGNED_INT,
+		IVec2:          constants.INT_VEC2,
+		IVec3:          constants.INT_VEC3,
+		IVec4:          constants.INT_VEC4,
+		UVec2:          constants.UNSIGNED_INT_VEC2,
+		UVec3:          constants.UNSIGNED_INT_VEC3,
+		UVec4:          constants.UNSIGNED_INT_VEC4,
+		Bool:           constants.BOOL,
+		Sampler2D:      constants.SAMPLER_2D,
+		Sampler3D:      constants.SAMPLER_3D,
+		SamplerCube:    constants.SAMPLER_CUBE,
+		Sampler2DArray: constants.SAMPLER_2D_ARRAY,
 	}
 	reverse := make(map[int]Type)
 	for typ, v := range jsConstants {
+		if v.IsUndefined() {
+			// WebGL1 lacks the WebGL2 types.
+			delete(jsConstants, typ)
+			continue
+		}
 		fNum, ok := v.ToFloat64()
 		if !ok {
 			panic(fmt.Errorf("js constant for %s not a Number: %T", typ, v))
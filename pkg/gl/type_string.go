@@ -21,11 +21,22 @@ func _() {
 	_ = x[UnsignedShort-11]
 	_ = x[Int-12]
 	_ = x[UnsignedInt-13]
+	_ = x[IVec2-14]
+	_ = x[IVec3-15]
+	_ = x[IVec4-16]
+	_ = x[UVec2-17]
+	_ = x[UVec3-18]
+	_ = x[UVec4-19]
+	_ = x[Bool-20]
+	_ = x[Sampler2D-21]
+	_ = x[Sampler3D-22]
+	_ = x[SamplerCube-23]
+	_ = x[Sampler2DArray-24]
 }
 
-const _Type_name = "FloatVec2Vec3Vec4Mat2Mat3Mat4ByteUnsignedByteShortUnsignedShortIntUnsignedInt"
+const _Type_name = "FloatVec2Vec3Vec4Mat2Mat3Mat4ByteUnsignedByteShortUnsignedShortIntUnsignedIntIVec2IVec3IVec4UVec2UVec3UVec4BoolSampler2DSampler3DSamplerCubeSampler2DArray"
 
-var _Type_index = [...]uint8{0, 5, 9, 13, 17, 21, 25, 29, 33, 45, 50, 63, 66, 77}
+var _Type_index = [...]uint8{0, 5, 9, 13, 17, 21, 25, 29, 33, 45, 50, 63, 66, 77, 82, 87, 92, 97, 102, 107, 111, 120, 129, 140, 154}
 
 func (i Type) String() string {
 	i -= 1
@@ -0,0 +1,155 @@
+package gl
+
+import (
+	"fmt"
+
+	"github.com/PieterD/warp/pkg/driver"
+)
+
+type webgl1Feature struct {
+	name      string
+	extension string
+	suffix    string
+	functions []string
+}
+
+// webgl1Features lists the WebGL2 functions that WebGL1 lacks, grouped by the feature they implement.
+// If a feature has an extension, its functions are taken from the extension, with the suffix added to their names.
+var webgl1Features = []webgl1Feature{
+	{
+		name:      "vertex array objects",
+		extension: "OES_vertex_array_object",
+		suffix:    "OES",
+		functions: []string{"createVertexArray", "deleteVertexArray", "bindVertexArray"},
+	},
+	{
+		name:      "instanced drawing",
+		extension: "ANGLE_instanced_arrays",
+		suffix:    "ANGLE",
+		functions: []string{"vertexAttribDivisor", "drawArraysInstanced", "drawElementsInstanced"},
+	},
+	{
+		name:      "uniform buffers",
+		functions: []string{"getUniformBlockIndex", "uniformBlockBinding", "bindBufferBase", "bindBufferRange", "getActiveUniforms", "getActiveUniformBlockParameter", "getActiveUniformBlockName"},
+	},
+	{
+		name:      "unsigned integer uniforms",
+		functions: []string{"uniform1ui", "uniform2ui", "uniform3ui", "uniform4ui", "uniform1uiv", "uniform2uiv", "uniform3uiv", "uniform4uiv"},
+	},
+	{
+		name:      "buffer readback",
+		functions: []string{"getBufferSubData"},
+	},
+	{
+		name:      "sync objects",
+		functions: []string{"fenceSync", "deleteSync", "waitSync", "clientWaitSync"},
+	},
+	{
+		name:      "transform feedback",
+		functions: []string{"createTransformFeedback", "deleteTransformFeedback", "bindTransformFeedback", "transformFeedbackVaryings", "beginTransformFeedback", "endTransformFeedback"},
+	},
+	{
+		name:      "queries",
+		functions: []string{"createQuery", "deleteQuery", "beginQuery", "endQuery", "getQuery", "getQueryParameter"},
+	},
+	{
+		name:      "multisampled renderbuffers",
+		functions: []string{"renderbufferStorageMultisample"},
+	},
+}
+
+// webgl1Fallback provides the WebGL2 functions missing from a WebGL1 context,
+// either from extensions or as stubs that panic, and keeps track of the features that are degraded.
+type webgl1Fallback struct {
+	factory  driver.Factory
+	obj      driver.Object
+	features map[string]webgl1Feature
+	degraded []string
+}
+
+func newWebgl1Fallback(factory driver.Factory, obj driver.Object) *webgl1Fallback {
+	features := make(map[string]webgl1Feature)
+	for _, feature := range webgl1Features {
+		for _, functionName := range feature.functions {
+			features[functionName] = feature
+		}
+	}
+	return &webgl1Fallback{
+		factory:  factory,
+		obj:      obj,
+		features: features,
+	}
+}
+
+// function returns a replacement for a function that is missing from the WebGL1 context.
+func (webgl1 *webgl1Fallback) function(functionName string) func(args ...driver.Value) driver.Value {
+	feature, ok := webgl1.features[functionName]
+	if !ok {
+		feature = webgl1Feature{name: functionName}
+	}
+	if feature.extension != "" {
+		getExtension := driver.Bind(webgl1.obj, "getExtension")
+		if extension, ok := getExtension(webgl1.factory.String(feature.extension)).ToObject(); ok {
+			if function := driver.Bind(extension, functionName+feature.suffix); function != nil {
+				return function
+			}
+		}
+	}
+	webgl1.degrade(feature.name)
+	return func(args ...driver.Value) driver.Value {
+		panic(fmt.Errorf("%s is not supported: %s are unavailable in WebGL1", functionName, feature.name))
+	}
+}
+
+func (webgl1 *webgl1Fallback) degrade(name string) {
+	for _, degraded := range webgl1.degraded {
+		if degraded == name {
+			return
+		}
+	}
+	webgl1.degraded = append(webgl1.degraded, name)
+}
+
+// degradedFeatures returns the degraded features, in the order of webgl1Features.
+// Missing functions that are not part of a known feature come last.
+func (webgl1 *webgl1Fallback) degradedFeatures() []string {
+	var features []string
+	isKnown := make(map[string]bool)
+	for _, feature := range webgl1Features {
+		isKnown[feature.name] = true
+		for _, degraded := range webgl1.degraded {
+			if degraded == feature.name {
+				features = append(features, feature.name)
+			}
+		}
+	}
+	for _, degraded := range webgl1.degraded {
+		if !isKnown[degraded] {
+			features = append(features, degraded)
+		}
+	}
+	return features
+}
+
+// adapt converts calls using WebGL2 overloads to their WebGL1 equivalents.
+func (webgl1 *webgl1Fallback) adapt(functionName string, function func(args ...driver.Value) driver.Value) func(args ...driver.Value) driver.Value {
+	switch functionName {
+	case "bufferData":
+		// WebGL1 ignores srcOffset and length, and would upload the entire array.
+		return func(args ...driver.Value) driver.Value {
+			if len(args) != 5 {
+				return function(args...)
+			}
+			array, ok := args[1].ToObject()
+			if !ok {
+				panic(fmt.Errorf("bufferData source is not an object: %v", args[1]))
+			}
+			srcOffset, _ := args[3].ToFloat64()
+			length, _ := args[4].ToFloat64()
+			subarray := driver.Bind(array, "subarray")(webgl1.factory.Number(srcOffset), webgl1.factory.Number(srcOffset+length))
+			return function(args[0], subarray, args[2])
+		}
+	default:
+		return function
+	}
+}